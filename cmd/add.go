@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -19,8 +20,11 @@ import (
 )
 
 var (
-	flagAddName string
-	flagAddDir  string
+	flagAddName    string
+	flagAddDir     string
+	flagAddDomains []string
+	flagAddHost    string
+	flagAddRouters string
 )
 
 var addCmd = &cobra.Command{
@@ -39,7 +43,21 @@ Local path (registers an already-cloned directory):
   di add ~/projects/myapp
 
 Non-interactive mode:
-  di add ./existing-project --name myapp --yes`,
+  di add ./existing-project --name myapp --yes
+
+Services running on the host next to the containers (e.g. a vite dev server):
+  di add ./existing-project --host-services frontend:5173
+
+Extra domains (routed and issued certs alongside the .test domain):
+  di add ./existing-project --domain '*.myapp.example.dev'
+
+Compose files that already carry traefik.http.routers.* labels:
+  di add ./existing-project --existing-routers adopt   # rewrite their host rules to the devinfra domain
+  di add ./existing-project --existing-routers skip    # leave them alone, add no devinfra router
+
+If the project contains a committed .devinfra.yaml manifest, its name,
+services, flavors, domains, dependencies, and compose files are used instead
+of prompting.`,
 	GroupID: "project",
 	Args:    cobra.ExactArgs(1),
 	RunE:    runAdd,
@@ -48,6 +66,9 @@ Non-interactive mode:
 func init() {
 	addCmd.Flags().StringVar(&flagAddName, "name", "", "project name (default: derived from directory name)")
 	addCmd.Flags().StringVar(&flagAddDir, "dir", "", "clone destination directory (git URL only)")
+	addCmd.Flags().StringVar(&flagAddHost, "host-services", "", "services running on the host as name:port pairs (e.g., frontend:5173)")
+	addCmd.Flags().StringSliceVar(&flagAddDomains, "domain", nil, "extra domain to route, e.g. '*.myapp.example.dev' (repeatable)")
+	addCmd.Flags().StringVar(&flagAddRouters, "existing-routers", "", "what to do with Traefik routers already in the compose file: adopt, skip, or generate (default: prompt, or adopt with --yes)")
 	rootCmd.AddCommand(addCmd)
 }
 
@@ -109,6 +130,10 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	arg := args[0]
 	kind := classifyInput(arg)
 
+	if flagAddRouters != "" && !slices.Contains([]string{"adopt", "skip", "generate"}, flagAddRouters) {
+		return fmt.Errorf("--existing-routers must be adopt, skip, or generate")
+	}
+
 	// Load registry once for all validation
 	reg, err := config.LoadRegistry()
 	if err != nil {
@@ -187,8 +212,25 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("directory already registered as project %q; run 'di remove %s' first", existingName, existingName)
 	}
 
-	// Determine project name
+	// A committed .devinfra.yaml defines the project for the whole team
+	manifest, err := config.LoadManifest(dir)
+	if err != nil {
+		return err
+	}
+	if manifest != nil {
+		ui.Info("Found %s", config.ManifestFileName)
+		if err := validateFlavorNames(manifest.Flavors); err != nil {
+			return fmt.Errorf("invalid %s: %w", config.ManifestFileName, err)
+		}
+	}
+
+	// Determine project name: --name, then manifest, then directory/repo name
 	name := flagAddName
+	nameFromManifest := false
+	if name == "" && manifest != nil && manifest.Name != "" {
+		name = manifest.Name
+		nameFromManifest = true
+	}
 	if name == "" {
 		name = derivedName
 	}
@@ -215,7 +257,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		if err := huh.NewForm(huh.NewGroup(nameInput)).Run(); err != nil {
 			return err
 		}
-	} else if !flagYes && flagAddName == "" {
+	} else if !flagYes && flagAddName == "" && !nameFromManifest {
 		// Interactive: confirm/change name
 		nameInput := huh.NewInput().
 			Title("Project name").
@@ -243,11 +285,52 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	composeFileName := compose.FindComposeFile(dir)
 
 	var selectedServices []config.Service
+	var composeOverrides []string
+	var flavors []string
+	hostMode := false
+	extraDomains := flagAddDomains
+	var dependsOn, profiles []string
+	var detected []compose.DetectedService
+
+	if manifest != nil {
+		// Read the manifest the way 'di sync' does, so the two agree
+		var fromManifest config.Project
+		manifest.ApplyTo(&fromManifest)
+		selectedServices = fromManifest.Services
+		flavors = fromManifest.Flavors
+		hostMode = fromManifest.HostMode
+		if len(extraDomains) == 0 {
+			extraDomains = fromManifest.ExtraDomains
+		}
+		dependsOn = fromManifest.DependsOn
+		profiles = fromManifest.Profiles
+		if fromManifest.ComposeFile != "" {
+			composeFileName = fromManifest.ComposeFile
+			composeOverrides = fromManifest.ComposeOverrides
+		}
+		for _, f := range manifest.ComposeFiles {
+			if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+				ui.Warn("Compose file %s listed in %s does not exist", f, config.ManifestFileName)
+			}
+		}
+	}
 
-	if composeFileName != "" {
+	if manifest != nil && manifest.Services != nil {
+		ui.Info("Using services from %s", config.ManifestFileName)
+	} else if composeFileName != "" {
 		ui.Info("Found %s", composeFileName)
 
-		detected, err := compose.ParseServices(dir, composeFileName)
+		// docker compose only loads the default override file when no -f is
+		// given, and devinfra always passes -f, so register it explicitly.
+		if manifest == nil || len(manifest.ComposeFiles) == 0 {
+			if override := compose.FindOverrideFile(dir, composeFileName); override != "" {
+				ui.Info("Found %s", override)
+				composeOverrides = append(composeOverrides, override)
+			}
+		}
+
+		files := append([]string{composeFileName}, composeOverrides...)
+		detected, err = compose.ParseServices(dir, files)
 		if err != nil {
 			ui.Warn("Could not parse compose file: %v", err)
 		} else {
@@ -264,23 +347,86 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Host services run outside docker but share the project's domain and cert
+	if manifest == nil || manifest.Services == nil {
+		var hostServices []config.Service
+		if flagAddHost != "" {
+			hostServices, err = parseServices(flagAddHost)
+		} else if !flagYes {
+			hostServices, err = promptHostServices()
+		}
+		if err != nil {
+			return err
+		}
+		for _, svc := range hostServices {
+			for _, existing := range selectedServices {
+				if existing.Name == svc.Name || existing.Port == svc.Port {
+					return fmt.Errorf("host service %s:%d conflicts with service %s:%d", svc.Name, svc.Port, existing.Name, existing.Port)
+				}
+			}
+			svc.Host = true
+			selectedServices = append(selectedServices, svc)
+		}
+		// Without a compose file there are no containers to mix with
+		if composeFileName == "" && len(hostServices) > 0 && len(hostServices) == len(selectedServices) {
+			hostMode = true
+		}
+	}
+
 	// Validate all service names
 	for _, svc := range selectedServices {
 		if err := config.ValidateName(svc.Name); err != nil {
 			return fmt.Errorf("service name %q is invalid: %w", svc.Name, err)
 		}
 	}
+	for _, d := range extraDomains {
+		if err := config.ValidateExtraDomain(d); err != nil {
+			return err
+		}
+	}
 
+	selectedServices, err = chooseRouting(selectedServices, detected)
+	if err != nil {
+		return err
+	}
+
+	project.TemplatesFS = embeddedTemplatesFS
 	return project.Add(ctx, project.AddOpts{
-		Name:        name,
-		Dir:         dir,
-		Services:    selectedServices,
-		ComposeFile: composeFileName,
-		Cloned:      cloned,
+		Name:             name,
+		Dir:              dir,
+		HostMode:         hostMode,
+		Services:         selectedServices,
+		Flavors:          flavors,
+		ComposeFile:      composeFileName,
+		ComposeOverrides: composeOverrides,
+		ExtraDomains:     extraDomains,
+		DependsOn:        dependsOn,
+		Profiles:         profiles,
+		Cloned:           cloned,
+		Settings:         config.Current(),
 	})
 }
 
-// promptServiceSelection shows detected services and lets the user pick which get routing.
+// validateFlavorNames checks that every flavor has an embedded template.
+func validateFlavorNames(flavors []string) error {
+	available := discoverFlavors()
+	for _, f := range flavors {
+		found := false
+		for _, a := range available {
+			if a == f {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown flavor %q; available: %s", f, strings.Join(available, ", "))
+		}
+	}
+	return nil
+}
+
+// promptServiceSelection shows detected services and lets the user pick which
+// get routing, and which port to route when a service has several candidates.
 func promptServiceSelection(detected []compose.DetectedService) ([]config.Service, error) {
 	// Sort services: those with ports first, then alphabetically
 	sort.Slice(detected, func(i, j int) bool {
@@ -315,19 +461,27 @@ func promptServiceSelection(detected []compose.DetectedService) ([]config.Servic
 	}
 
 	if flagYes {
-		// Auto-select all services with ports
+		// Auto-select services whose best port is likely HTTP
 		var services []config.Service
 		for _, svc := range portServices {
-			services = append(services, config.Service{Name: svc.Name, Port: svc.Port})
+			if svc.Candidates[0].Confidence > compose.ConfidenceLow {
+				services = append(services, config.Service{Name: svc.Name, Port: svc.Port})
+			}
 		}
 		return services, nil
 	}
 
-	// Interactive multi-select
+	// Interactive multi-select; likely non-HTTP services start unselected
 	opts := make([]huh.Option[string], len(portServices))
 	for i, svc := range portServices {
 		label := fmt.Sprintf("%s (port %d)", svc.Name, svc.Port)
-		opts[i] = huh.NewOption(label, svc.Name).Selected(true)
+		if len(svc.Candidates) > 1 {
+			label = fmt.Sprintf("%s (ports %s)", svc.Name, formatCandidatePorts(svc.Candidates))
+		}
+		if len(svc.Profiles) > 0 {
+			label += fmt.Sprintf(" [profiles: %s]", strings.Join(svc.Profiles, ", "))
+		}
+		opts[i] = huh.NewOption(label, svc.Name).Selected(svc.Candidates[0].Confidence > compose.ConfidenceLow)
 	}
 
 	var selectedNames []string
@@ -345,15 +499,167 @@ func promptServiceSelection(detected []compose.DetectedService) ([]config.Servic
 	for _, name := range selectedNames {
 		for _, svc := range portServices {
 			if svc.Name == name {
-				services = append(services, config.Service{Name: svc.Name, Port: svc.Port})
+				port, err := promptPortChoice(svc)
+				if err != nil {
+					return nil, err
+				}
+				services = append(services, config.Service{Name: svc.Name, Port: port})
 				break
 			}
 		}
 	}
 
+	return promptSubdomains(services)
+}
+
+// promptPortChoice asks which port to route for a service with several
+// candidates, defaulting to the most likely one.
+func promptPortChoice(svc compose.DetectedService) (int, error) {
+	if len(svc.Candidates) < 2 {
+		return svc.Port, nil
+	}
+
+	opts := make([]huh.Option[int], len(svc.Candidates))
+	for i, c := range svc.Candidates {
+		opts[i] = huh.NewOption(fmt.Sprintf("%d (%s, %s confidence)", c.Port, c.Source, c.Confidence), c.Port)
+	}
+	port := svc.Port
+	portSelect := huh.NewSelect[int]().
+		Title(fmt.Sprintf("Port to route for %s", svc.Name)).
+		Options(opts...).
+		Value(&port)
+	if err := huh.NewForm(huh.NewGroup(portSelect)).Run(); err != nil {
+		return 0, err
+	}
+	return port, nil
+}
+
+func formatCandidatePorts(candidates []compose.PortCandidate) string {
+	ports := make([]string, len(candidates))
+	for i, c := range candidates {
+		ports[i] = fmt.Sprint(c.Port)
+	}
+	return strings.Join(ports, ", ")
+}
+
+// chooseRouting decides, for each selected docker service whose compose
+// file already defines Traefik routers, whether devinfra adopts them, skips
+// them, or generates its own router alongside. Services that already have a
+// routing mode (from .devinfra.yaml) are left alone.
+func chooseRouting(services []config.Service, detected []compose.DetectedService) ([]config.Service, error) {
+	for i, svc := range services {
+		if svc.Host || svc.Routing != config.RoutingGenerate {
+			continue
+		}
+		var traefik compose.TraefikObjects
+		for _, d := range detected {
+			if d.Name == svc.Name {
+				traefik = d.Traefik()
+			}
+		}
+		if len(traefik.Routers) == 0 {
+			continue
+		}
+
+		ui.Info("Service %s already defines Traefik routers: %s", svc.Name, strings.Join(traefik.Routers, ", "))
+		if len(traefik.Services) > 0 {
+			fmt.Fprintf(os.Stderr, "  services:    %s\n", strings.Join(traefik.Services, ", "))
+		}
+		if len(traefik.Middlewares) > 0 {
+			fmt.Fprintf(os.Stderr, "  middlewares: %s\n", strings.Join(traefik.Middlewares, ", "))
+		}
+
+		choice := flagAddRouters
+		if choice == "" && flagYes {
+			choice = "adopt"
+		}
+		if choice == "" {
+			choice = "adopt"
+			choiceSelect := huh.NewSelect[string]().
+				Title(fmt.Sprintf("How should devinfra route %s?", svc.Name)).
+				Options(
+					huh.NewOption(fmt.Sprintf("Adopt the existing routers (rewrite their host rules to .%s)", config.TLD()), "adopt"),
+					huh.NewOption("Skip (keep the existing routers as they are)", "skip"),
+					huh.NewOption("Generate a devinfra router alongside them (may conflict)", "generate"),
+				).
+				Value(&choice)
+			if err := huh.NewForm(huh.NewGroup(choiceSelect)).Run(); err != nil {
+				return nil, err
+			}
+		}
+
+		switch choice {
+		case "adopt":
+			services[i].Routing = config.RoutingAdopt
+		case "skip":
+			services[i].Routing = config.RoutingSkip
+		}
+	}
+	return services, nil
+}
+
+// promptSubdomains lets the user override the subdomain each service is
+// routed on. Leaving an input empty keeps the service name; "@" routes the
+// service on the bare project domain.
+func promptSubdomains(services []config.Service) ([]config.Service, error) {
+	if len(services) == 0 {
+		return services, nil
+	}
+
+	subdomains := make([]string, len(services))
+	fields := make([]huh.Field, len(services))
+	for i, svc := range services {
+		fields[i] = huh.NewInput().
+			Title(fmt.Sprintf("Subdomain for %s", svc.Name)).
+			Description("Empty keeps the service name; @ routes the root domain").
+			Placeholder(svc.Name).
+			Value(&subdomains[i]).
+			Validate(func(s string) error {
+				if s == "" {
+					return nil
+				}
+				if err := config.ValidateSubdomain(s); err != nil {
+					return err
+				}
+				for j := range services {
+					if j != i && (subdomains[j] == s || (subdomains[j] == "" && services[j].Name == s)) {
+						return fmt.Errorf("subdomain %q is already used by %s", s, services[j].Name)
+					}
+				}
+				return nil
+			})
+	}
+
+	if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return nil, err
+	}
+
+	for i := range services {
+		if subdomains[i] != "" && subdomains[i] != services[i].Name {
+			services[i].Subdomain = subdomains[i]
+		}
+	}
 	return services, nil
 }
 
+// promptHostServices asks for services that run directly on the host, such as
+// a frontend dev server, to be routed alongside the project's containers.
+func promptHostServices() ([]config.Service, error) {
+	var hasHost bool
+	confirm := huh.NewConfirm().
+		Title("Do any services run on the host (e.g. a dev server)?").
+		Affirmative("Yes").
+		Negative("No").
+		Value(&hasHost)
+	if err := huh.NewForm(huh.NewGroup(confirm)).Run(); err != nil {
+		return nil, err
+	}
+	if !hasHost {
+		return nil, nil
+	}
+	return promptNewServices()
+}
+
 // promptNoCompose handles the case where no compose file is found.
 func promptNoCompose() ([]config.Service, error) {
 	if flagYes {
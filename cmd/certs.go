@@ -3,6 +3,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/heysarver/devinfra/internal/compose"
@@ -29,12 +30,35 @@ var certsRegenCmd = &cobra.Command{
 }
 
 func init() {
+	addSelectorFlags(certsRegenCmd)
 	certsCmd.AddCommand(certsRegenCmd)
 	rootCmd.AddCommand(certsCmd)
 }
 
 func runCertsRegen(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
+	settings := config.Current()
+
+	// Tag selection: regenerate the matching projects only, not infra
+	if !projectFilter().IsZero() {
+		projects, err := selectedProjects(args)
+		if err != nil {
+			return err
+		}
+		var failed []string
+		for _, p := range projects {
+			ui.Info("Regenerating certs for %s...", p.Name)
+			if err := compose.GenerateCerts(ctx, settings, p.Name, p.ExtraDomains); err != nil {
+				ui.Warn("Failed to regenerate certs for %s: %v", p.Name, err)
+				failed = append(failed, p.Name)
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("regenerating certs failed for: %s", strings.Join(failed, ", "))
+		}
+		ui.Ok("Certificates regenerated for %d project(s).", len(projects))
+		return nil
+	}
 
 	// When no project arg and not skipping interactivity, show picker with "All projects"
 	if len(args) == 0 && !flagYes {
@@ -68,7 +92,7 @@ func runCertsRegen(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		// Regenerate all: infra + all projects
 		ui.Info("Regenerating infrastructure certs...")
-		if err := compose.GenerateInfraCerts(ctx); err != nil {
+		if err := compose.GenerateInfraCerts(ctx, settings); err != nil {
 			return fmt.Errorf("regenerating infra certs: %w", err)
 		}
 
@@ -78,7 +102,7 @@ func runCertsRegen(cmd *cobra.Command, args []string) error {
 		}
 		for _, p := range reg.Projects {
 			ui.Info("Regenerating certs for %s...", p.Name)
-			if err := compose.GenerateCerts(ctx, p.Name); err != nil {
+			if err := compose.GenerateCerts(ctx, settings, p.Name, p.ExtraDomains); err != nil {
 				ui.Warn("Failed to regenerate certs for %s: %v", p.Name, err)
 			}
 		}
@@ -93,11 +117,12 @@ func runCertsRegen(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	if reg.Get(name) == nil {
+	p := reg.Get(name)
+	if p == nil {
 		return fmt.Errorf("project %q not found in registry", name)
 	}
 
-	if err := compose.GenerateCerts(ctx, name); err != nil {
+	if err := compose.GenerateCerts(ctx, settings, name, p.ExtraDomains); err != nil {
 		return fmt.Errorf("regenerating certs for %s: %w", name, err)
 	}
 	ui.Ok("Certificates regenerated for %s.", name)
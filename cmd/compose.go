@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var composeCmd = &cobra.Command{
+	Use:   "compose <project> -- <compose args...>",
+	Short: "Run a docker compose command for a project",
+	Long:  "Run any compose command for a project with the same project name, compose file list (base, overrides, devinfra overlay, and flavors), default profiles, and environment di itself uses. This is the canonical way for Makefiles and scripts to drive a project's containers.\n\nCompose's global flags, such as --profile, go after the --.",
+	Example: `  di compose myapp -- ps
+  di compose myapp -- pull
+  di compose myapp -- config
+  di compose myapp -- --profile workers up -d worker`,
+	GroupID:           "project",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: projectNameCompletion,
+	RunE:              runCompose,
+}
+
+func init() {
+	// Everything after the project belongs to compose
+	composeCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(composeCmd)
+}
+
+func runCompose(cmd *cobra.Command, args []string) error {
+	name, composeArgs := args[0], args[1:]
+	if len(composeArgs) > 0 && composeArgs[0] == "--" {
+		composeArgs = composeArgs[1:]
+	}
+	if len(composeArgs) == 0 {
+		return fmt.Errorf("no compose command given, e.g. 'di compose %s -- ps'", name)
+	}
+
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+	p := reg.Get(name)
+	if p == nil {
+		return fmt.Errorf("project %q not found in registry", name)
+	}
+	return passExitCode(compose.ProjectCompose(cmd.Context(), p.Name, p.Dir, p.ComposeFiles(), p.Profiles, composeArgs))
+}
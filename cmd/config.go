@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/heysarver/devinfra/internal/compose"
@@ -25,42 +30,323 @@ var configSetCmd = &cobra.Command{
 
 Supported keys:
   tld                          Local TLD (e.g. claw, test)
+  runtime                      Container runtime: docker, podman, or nerdctl
+  runtime.socket               Docker-compatible API socket for Traefik (default depends on runtime)
+  dns.port                     Host port dnsmasq listens on (default 5354)
+  dns.upstream                 Upstream DNS servers (e.g. 1.1.1.1,9.9.9.9#53)
+  dns.forward                  Per-domain forwarding (e.g. corp.internal=10.0.0.2,vpn.lan=10.8.0.1)
+  tcp.routing                  Route flavor databases and brokers by SNI on 5432/5672/6379 (true/false)
   remote.enabled               Enable cross-device remote domain (true/false)
   remote.domain                Remote base domain (e.g. claw.sarvent.cloud)
   remote.dns_provider          DNS provider for ACME challenge (cloudflare)
   remote.acme_email            Email for Let's Encrypt certificate notifications
-  remote.cloudflare_zone_token Cloudflare API token with Zone:DNS:Edit permission`,
+  remote.cloudflare_zone_token Cloudflare API token with Zone:DNS:Edit permission
+
+Changing tld or dns.port reconfigures everything that depends on it: the host
+resolver, dnsmasq, and (for tld) every project's certs and routing. Toggling
+tcp.routing re-renders Traefik's entrypoints and regenerates every project's
+overlay.`,
 	Args: cobra.ExactArgs(2),
 	RunE: runConfigSet,
 }
 
+var flagShowSecrets bool
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a configuration value",
+	Long: `Print the current value of a devinfra configuration key.
+
+Environment variables take precedence over the .env file in the config
+directory, which takes precedence over built-in defaults. Secrets such as
+remote.cloudflare_zone_token are redacted unless --show-secrets is passed.
+With --json, the value's source is included.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: configKeyCompletion,
+	RunE:              runConfigGet,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configuration values and where they come from",
+	Long: `List every devinfra configuration key with its current value and source:
+"env" (environment variable), ".env" (config directory .env file), "default",
+or "unset".
+
+Secrets are redacted unless --show-secrets is passed. Values that fail
+validation are flagged.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigList,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a configuration value from .env",
+	Long: `Remove a devinfra configuration key from the .env file so it falls back to
+its default. Unsetting tld re-extracts the infra configs and regenerates all
+projects, like 'di config set tld'. Remote settings required by
+remote.enabled cannot be unset while remote access is enabled.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: configKeyCompletion,
+	RunE:              runConfigUnset,
+}
+
+var flagMigrateDryRun bool
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade projects.yaml to the current schema version",
+	Long: `Upgrade projects.yaml to the schema version understood by this di.
+
+Older registries are also migrated automatically the next time they are
+saved; this command does it explicitly. A backup of the original file is kept
+next to it as projects.yaml.v<N>.bak.
+
+Use --dry-run to show the migrations and the resulting changes without
+writing anything.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigMigrate,
+}
+
 func init() {
+	configMigrateCmd.Flags().BoolVar(&flagMigrateDryRun, "dry-run", false, "show what would change without writing")
+	for _, c := range []*cobra.Command{configGetCmd, configListCmd} {
+		c.Flags().BoolVar(&flagShowSecrets, "show-secrets", false, "print secret values instead of redacting them")
+	}
+	configSetCmd.ValidArgsFunction = configKeyCompletion
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configListCmd)
 	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configMigrateCmd)
 	rootCmd.AddCommand(configCmd)
 }
 
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	var plan *config.MigrationPlan
+	var err error
+	if flagMigrateDryRun {
+		plan, err = config.PlanRegistryMigration()
+	} else {
+		plan, err = config.MigrateRegistry()
+	}
+	if err != nil {
+		return err
+	}
+
+	if flagJSON {
+		return ui.PrintJSON(plan)
+	}
+
+	if !plan.NeedsMigration() {
+		ui.Ok("projects.yaml is already at schema version %d.", plan.To)
+		return nil
+	}
+
+	for _, step := range plan.Steps {
+		ui.Info("v%d: %s", step.Version, step.Description)
+	}
+
+	if flagMigrateDryRun {
+		fmt.Println()
+		for _, line := range lineDiff(string(plan.Normalized), string(plan.After)) {
+			fmt.Println(line)
+		}
+		fmt.Println()
+		ui.Info("Dry run: projects.yaml would be migrated from schema version %d to %d.", plan.From, plan.To)
+		return nil
+	}
+
+	ui.Ok("projects.yaml migrated from schema version %d to %d.", plan.From, plan.To)
+	fmt.Fprintf(os.Stderr, "  Backup: %s\n", config.RegistryBackupPath(plan.From))
+	return nil
+}
+
+// lineDiff returns a minimal line-based diff of a and b, prefixing removed
+// lines with "-", added lines with "+", and unchanged lines with " ".
+func lineDiff(a, b string) []string {
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			out = append(out, " "+x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+x[i])
+			i++
+		default:
+			out = append(out, "+"+y[j])
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		out = append(out, "-"+x[i])
+	}
+	for ; j < len(y); j++ {
+		out = append(out, "+"+y[j])
+	}
+	return out
+}
+
+// configKeyCompletion completes the first argument with supported config keys.
+func configKeyCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return config.KeyNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	key, err := config.LookupKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	v := key.Resolve()
+	if !flagShowSecrets {
+		v = v.Redacted()
+	}
+	if flagJSON {
+		return ui.PrintJSON(v)
+	}
+	if v.Error != "" {
+		ui.Warn("%s: %s", v.Key, v.Error)
+	}
+	fmt.Println(v.Value)
+	return nil
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	values := config.ResolveAll()
+	if !flagShowSecrets {
+		for i := range values {
+			values[i] = values[i].Redacted()
+		}
+	}
+	if flagJSON {
+		return ui.PrintJSON(values)
+	}
+
+	var rows [][]string
+	for _, v := range values {
+		value := v.Value
+		if v.Error != "" {
+			value += " (invalid)"
+		}
+		rows = append(rows, []string{v.Key, value, v.Source, v.Env})
+	}
+	ui.PrintTable([]string{"KEY", "VALUE", "SOURCE", "VARIABLE"}, rows)
+
+	for _, v := range values {
+		if v.Error != "" {
+			ui.Warn("%s: %s", v.Key, v.Error)
+		}
+	}
+	return nil
+}
+
 func runConfigSet(cmd *cobra.Command, args []string) error {
-	key := args[0]
+	key, err := config.LookupKey(args[0])
+	if err != nil {
+		return err
+	}
 	value := args[1]
 
-	switch key {
+	switch key.Name {
 	case "tld":
 		return setTLD(cmd, value)
+	case "runtime":
+		return setRuntime(cmd, value)
+	case "runtime.socket":
+		return setRuntimeSocket(cmd, key, value)
+	case "dns.port":
+		return setDNSPort(cmd, value)
+	case "dns.upstream", "dns.forward":
+		return setDNSServers(cmd, key, value)
 	case "remote.enabled":
 		return setRemoteEnabled(value)
-	case "remote.domain":
-		return setRemoteValue("REMOTE_DOMAIN", value, config.ValidateRemoteDomain)
-	case "remote.dns_provider":
-		return setRemoteValue("REMOTE_DNS_PROVIDER", value, nil)
-	case "remote.acme_email":
-		return setRemoteValue("REMOTE_ACME_EMAIL", value, config.ValidateACMEEmail)
-	case "remote.cloudflare_zone_token":
-		return setRemoteValue("CF_DNS_API_TOKEN", value, nil)
+	case "tcp.routing":
+		return setTCPRouting(cmd, key, value)
 	default:
-		return fmt.Errorf("unsupported config key %q; run 'di config set --help' for supported keys", key)
+		return setRemoteValue(key.Env, value, key.Validate)
 	}
 }
 
+// remoteRequiredKeys are the settings that must stay set while remote access
+// is enabled; setRemoteEnabled checks the same list.
+var remoteRequiredKeys = []string{"remote.domain", "remote.acme_email", "remote.cloudflare_zone_token"}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	key, err := config.LookupKey(args[0])
+	if err != nil {
+		return err
+	}
+	if slices.Contains(remoteRequiredKeys, key.Name) && config.RemoteEnabled() {
+		return fmt.Errorf("cannot unset %s while remote access is enabled; run 'di config set remote.enabled false' first", key.Name)
+	}
+
+	if key.Name == "runtime" {
+		if err := checkRuntimeStopped(cmd); err != nil {
+			return err
+		}
+	}
+
+	before := key.Resolve()
+	removed, err := deleteEnvKey(key.Env)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", key.Name, err)
+	}
+	if !removed {
+		ui.Info("%s is not set in %s", key.Name, config.EnvFilePath())
+	} else {
+		ui.Ok("%s unset", key.Name)
+	}
+	if os.Getenv(key.Env) != "" {
+		ui.Warn("$%s is set in the environment and still overrides %s.", key.Env, key.Name)
+	}
+
+	after := key.Resolve()
+	if !removed || after.Value == before.Value {
+		return nil
+	}
+	switch key.Name {
+	case "tld":
+		ui.Info("TLD reverts from %q to %q...", before.Value, after.Value)
+		return applyTLD(cmd)
+	case "dns.port":
+		ui.Info("DNS port reverts from %s to %s...", before.Value, after.Value)
+		oldPort, _ := strconv.Atoi(before.Value)
+		return applyDNSPort(cmd, oldPort)
+	case "dns.upstream", "dns.forward":
+		return applyDNSServers(cmd)
+	case "runtime", "runtime.socket":
+		return applyRuntime(cmd)
+	case "tcp.routing":
+		return applyTCPRouting(cmd)
+	}
+	return nil
+}
+
 // setRemoteEnabled validates prerequisites then writes REMOTE_ENABLED to .env.
 func setRemoteEnabled(value string) error {
 	if value != "true" && value != "false" {
@@ -69,16 +355,11 @@ func setRemoteEnabled(value string) error {
 
 	if value == "true" {
 		// Validate that required fields are already set
-		r := config.Remote()
 		var missing []string
-		if r.Domain == "" {
-			missing = append(missing, "remote.domain")
-		}
-		if r.ACMEEmail == "" {
-			missing = append(missing, "remote.acme_email")
-		}
-		if r.CloudflareToken == "" {
-			missing = append(missing, "remote.cloudflare_zone_token")
+		for _, name := range remoteRequiredKeys {
+			if key, _ := config.LookupKey(name); key.Resolve().Value == "" {
+				missing = append(missing, name)
+			}
 		}
 		if len(missing) > 0 {
 			return fmt.Errorf("cannot enable remote access: the following must be set first:\n  %s\n\nRun 'di config set <key> <value>' for each.", strings.Join(missing, "\n  "))
@@ -110,28 +391,67 @@ func setRemoteValue(envKey, value string, validate func(string) error) error {
 }
 
 // writeEnvKey reads the existing .env file, updates or appends the given key,
-// and writes it back with 0600 permissions.
+// and writes it back with 0600 permissions. The read-modify-write runs under
+// the config directory lock so parallel invocations don't drop each other's keys.
 func writeEnvKey(key, value string) error {
-	envPath := config.EnvFilePath()
-	data, _ := os.ReadFile(envPath)
-	lines := strings.Split(string(data), "\n")
-	found := false
-	prefix := key + "="
-	for i, line := range lines {
-		if strings.HasPrefix(line, prefix) {
-			lines[i] = prefix + value
-			found = true
+	return config.WithLock(func() error {
+		envPath := config.EnvFilePath()
+		data, _ := os.ReadFile(envPath)
+		lines := strings.Split(string(data), "\n")
+		found := false
+		prefix := key + "="
+		for i, line := range lines {
+			if strings.HasPrefix(line, prefix) {
+				lines[i] = prefix + value
+				found = true
+			}
 		}
-	}
-	if !found {
-		lines = append(lines, prefix+value)
-	}
-	return os.WriteFile(envPath, []byte(strings.Join(lines, "\n")), 0600)
+		if !found {
+			lines = append(lines, prefix+value)
+		}
+		if err := os.WriteFile(envPath, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+			return err
+		}
+		_, _ = config.Reload()
+		return nil
+	})
 }
 
-func setTLD(cmd *cobra.Command, newTLD string) error {
-	ctx := cmd.Context()
+// deleteEnvKey removes the given key from the .env file under the config
+// directory lock. It reports whether the key was present.
+func deleteEnvKey(key string) (bool, error) {
+	removed := false
+	err := config.WithLock(func() error {
+		envPath := config.EnvFilePath()
+		data, err := os.ReadFile(envPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		prefix := key + "="
+		var kept []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, prefix) {
+				removed = true
+				continue
+			}
+			kept = append(kept, line)
+		}
+		if !removed {
+			return nil
+		}
+		if err := os.WriteFile(envPath, []byte(strings.Join(kept, "\n")), 0600); err != nil {
+			return err
+		}
+		_, _ = config.Reload()
+		return nil
+	})
+	return removed, err
+}
 
+func setTLD(cmd *cobra.Command, newTLD string) error {
 	if err := config.ValidateTLD(newTLD); err != nil {
 		return err
 	}
@@ -149,16 +469,28 @@ func setTLD(cmd *cobra.Command, newTLD string) error {
 		return fmt.Errorf("writing TLD to .env: %w", err)
 	}
 
+	if os.Getenv("TLD") != "" {
+		ui.Warn("$TLD is set in the environment and still overrides the TLD in .env.")
+	}
+	return applyTLD(cmd)
+}
+
+// applyTLD re-renders the infra configs and every project for a TLD that has
+// already been written to .env.
+func applyTLD(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	settings := config.Current()
+
 	// Re-extract embedded files (dnsmasq.conf, docker-compose.yaml, tls-infra.yaml)
 	// with the new TLD so DNS and Traefik infra configs are updated.
 	ui.Info("Re-extracting embedded configs with new TLD...")
-	if err := compose.ExtractEmbedded(newTLD); err != nil {
+	if err := compose.ExtractEmbedded(settings); err != nil {
 		return fmt.Errorf("extracting embedded configs: %w", err)
 	}
 
 	// Regenerate all project overlays, certs, and dynamic configs.
 	// This also restarts infra and any previously-running projects.
-	return project.RegenerateAll(ctx)
+	return project.RegenerateAll(ctx, settings)
 }
 
 // writeTLDToEnv reads the existing .env file and replaces only the TLD= line,
@@ -166,3 +498,259 @@ func setTLD(cmd *cobra.Command, newTLD string) error {
 func writeTLDToEnv(newTLD string) error {
 	return writeEnvKey("TLD", newTLD)
 }
+
+func setDNSPort(cmd *cobra.Command, value string) error {
+	key, err := config.LookupKey("dns.port")
+	if err != nil {
+		return err
+	}
+	if err := key.Validate(value); err != nil {
+		return err
+	}
+	port, _ := strconv.Atoi(value)
+
+	current := config.Current().DNSPort
+	if port == current {
+		ui.Info("DNS port is already set to %d", port)
+		return nil
+	}
+	if err := checkDNSPortFree(port); err != nil {
+		return err
+	}
+
+	ui.Info("Changing DNS port from %d to %d...", current, port)
+	if err := writeEnvKey("DNS_PORT", value); err != nil {
+		return fmt.Errorf("writing DNS_PORT to .env: %w", err)
+	}
+	if os.Getenv("DNS_PORT") != "" {
+		ui.Warn("$DNS_PORT is set in the environment and still overrides the port in .env.")
+	}
+	return applyDNSPort(cmd, current)
+}
+
+// checkDNSPortFree rejects a DNS port that a registered service already uses,
+// since project ports may not collide with reserved ports, or that another
+// process has bound on the host.
+func checkDNSPortFree(port int) error {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+	for _, p := range reg.Projects {
+		for _, s := range p.Services {
+			if s.Port == port {
+				return fmt.Errorf("port %d is used by service '%s' of project '%s'", port, s.Name, p.Name)
+			}
+		}
+	}
+
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("port %d is not free: %w", port, err)
+	}
+	_ = conn.Close()
+	return nil
+}
+
+// applyDNSPort points the host resolver and the dnsmasq container at a DNS
+// port that has already been written to .env.
+func applyDNSPort(cmd *cobra.Command, oldPort int) error {
+	ctx := cmd.Context()
+	settings := config.Current()
+
+	platform := runtime.GOOS
+	if platform != "darwin" && platform != "linux" {
+		ui.Warn("Platform %s not supported for automatic resolver setup; point .%s lookups at 127.0.0.1:%d yourself.", platform, settings.TLD, settings.DNSPort)
+	} else {
+		ui.Info("Updating the host resolver (may prompt for sudo)...")
+		scriptPath, err := compose.ExtractResolverScript(platform, settings)
+		if err != nil {
+			return fmt.Errorf("extracting resolver script: %w", err)
+		}
+		resolverCmd := exec.CommandContext(ctx, "bash", scriptPath)
+		resolverCmd.Stdout = os.Stderr
+		resolverCmd.Stderr = os.Stderr
+		resolverCmd.Stdin = os.Stdin
+		resolverCmd.Env = append(os.Environ(),
+			fmt.Sprintf("DNS_PORT=%d", settings.DNSPort),
+			fmt.Sprintf("OLD_DNS_PORT=%d", oldPort))
+		if err := resolverCmd.Run(); err != nil {
+			ui.Warn("Resolver update had issues: %v", err)
+			ui.Warn("Run 'di doctor' to check what needs fixing.")
+		}
+	}
+
+	if compose.IsInfraRunning(ctx) {
+		ui.Info("Restarting dnsmasq on port %d...", settings.DNSPort)
+		if err := compose.RestartDNS(ctx); err != nil {
+			return fmt.Errorf("restarting dnsmasq: %w", err)
+		}
+	}
+
+	ui.Ok("DNS port set to %d", settings.DNSPort)
+	return nil
+}
+
+// setDNSServers writes the upstream servers or forwarding rules for dnsmasq,
+// then re-renders dnsmasq.conf and reloads dnsmasq.
+func setDNSServers(cmd *cobra.Command, key config.Key, value string) error {
+	if err := key.Validate(value); err != nil {
+		return err
+	}
+	if key.Name == "dns.forward" {
+		tld := config.TLD()
+		rules, _ := config.ParseDNSForwards(value)
+		for _, r := range rules {
+			if r.Domain == tld || strings.HasSuffix(r.Domain, "."+tld) {
+				return fmt.Errorf("cannot forward %s: .%s is answered by devinfra itself", r.Domain, tld)
+			}
+		}
+	}
+
+	if err := writeEnvKey(key.Env, value); err != nil {
+		return fmt.Errorf("writing %s to .env: %w", key.Env, err)
+	}
+	if os.Getenv(key.Env) != "" {
+		ui.Warn("$%s is set in the environment and still overrides %s.", key.Env, key.Name)
+	}
+	ui.Ok("%s set to %q", key.Name, value)
+	return applyDNSServers(cmd)
+}
+
+// applyDNSServers re-renders dnsmasq.conf from the current settings and
+// reloads dnsmasq if the infra is running. On Linux the host dnsmasq that
+// 'di init' set up gets the same servers.
+func applyDNSServers(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	settings := config.Current()
+
+	if err := compose.ExtractEmbedded(settings); err != nil {
+		return fmt.Errorf("extracting embedded configs: %w", err)
+	}
+	if runtime.GOOS == "linux" {
+		ui.Info("Updating the host dnsmasq (may prompt for sudo)...")
+		scriptPath, err := compose.ExtractDNSServersScript(runtime.GOOS, settings)
+		if err != nil {
+			return fmt.Errorf("extracting DNS servers script: %w", err)
+		}
+		serversCmd := exec.CommandContext(ctx, "bash", scriptPath)
+		serversCmd.Stdout = os.Stderr
+		serversCmd.Stderr = os.Stderr
+		serversCmd.Stdin = os.Stdin
+		if err := serversCmd.Run(); err != nil {
+			ui.Warn("Host dnsmasq update had issues: %v", err)
+			ui.Warn("Run 'di doctor' to check what needs fixing.")
+		}
+	}
+	if compose.IsInfraRunning(ctx) {
+		ui.Info("Reloading dnsmasq...")
+		if err := compose.RestartDNS(ctx); err != nil {
+			return fmt.Errorf("restarting dnsmasq: %w", err)
+		}
+	} else {
+		ui.Info("dnsmasq will use the new servers the next time you run 'di up'.")
+	}
+	return nil
+}
+
+// setTCPRouting turns the Traefik TCP entrypoints for flavor databases and
+// brokers on or off.
+func setTCPRouting(cmd *cobra.Command, key config.Key, value string) error {
+	if err := key.Validate(value); err != nil {
+		return err
+	}
+	if err := writeEnvKey(key.Env, value); err != nil {
+		return fmt.Errorf("writing %s to .env: %w", key.Env, err)
+	}
+	if os.Getenv(key.Env) != "" {
+		ui.Warn("$%s is set in the environment and still overrides %s.", key.Env, key.Name)
+	}
+	ui.Ok("%s set to %q", key.Name, value)
+	return applyTCPRouting(cmd)
+}
+
+// applyTCPRouting re-renders the infra compose file with or without the TCP
+// entrypoints and regenerates every project's overlay to match.
+func applyTCPRouting(cmd *cobra.Command) error {
+	settings := config.Current()
+	if err := compose.ExtractEmbedded(settings); err != nil {
+		return fmt.Errorf("extracting embedded configs: %w", err)
+	}
+	return project.RegenerateAll(cmd.Context(), settings)
+}
+
+// setRuntime switches the container runtime. Containers started by the old
+// runtime are invisible to the new one, so the infra must be stopped first.
+func setRuntime(cmd *cobra.Command, value string) error {
+	key, err := config.LookupKey("runtime")
+	if err != nil {
+		return err
+	}
+	if err := key.Validate(value); err != nil {
+		return err
+	}
+
+	current := config.Current().Runtime
+	if value == current {
+		ui.Info("Runtime is already set to %q", value)
+		return nil
+	}
+	if _, err := exec.LookPath(value); err != nil {
+		return fmt.Errorf("%s not found in PATH; install it before switching runtimes", value)
+	}
+	if err := checkRuntimeStopped(cmd); err != nil {
+		return err
+	}
+
+	if err := writeEnvKey(key.Env, value); err != nil {
+		return fmt.Errorf("writing %s to .env: %w", key.Env, err)
+	}
+	if os.Getenv(key.Env) != "" {
+		ui.Warn("$%s is set in the environment and still overrides the runtime in .env.", key.Env)
+	}
+	ui.Ok("Runtime changed from %q to %q", current, value)
+	return applyRuntime(cmd)
+}
+
+// setRuntimeSocket overrides the API socket mounted into socket-proxy.
+func setRuntimeSocket(cmd *cobra.Command, key config.Key, value string) error {
+	if err := key.Validate(value); err != nil {
+		return err
+	}
+	if err := writeEnvKey(key.Env, value); err != nil {
+		return fmt.Errorf("writing %s to .env: %w", key.Env, err)
+	}
+	ui.Ok("%s set to %q", key.Name, value)
+	return applyRuntime(cmd)
+}
+
+// checkRuntimeStopped refuses to change runtimes while the current runtime's
+// infra is running, since the new runtime couldn't stop it.
+func checkRuntimeStopped(cmd *cobra.Command) error {
+	if compose.IsInfraRunning(cmd.Context()) {
+		return fmt.Errorf("infrastructure is running under %s; stop it and your projects first with 'di down --all && di down'", config.Current().Runtime)
+	}
+	return nil
+}
+
+// applyRuntime re-renders the infra compose file for the selected runtime's
+// socket and makes sure its traefik network exists.
+func applyRuntime(cmd *cobra.Command) error {
+	settings := config.Current()
+	rt := compose.RuntimeFor(settings)
+
+	if err := compose.ExtractEmbedded(settings); err != nil {
+		return fmt.Errorf("extracting embedded configs: %w", err)
+	}
+	if rt.Socket() == "" {
+		ui.Warn("%s has no Docker-compatible API, so Traefik can't discover container routes; point runtime.socket at one.", rt.Name())
+	}
+	_ = compose.CreateNetwork(cmd.Context())
+
+	if compose.IsInfraRunning(cmd.Context()) {
+		ui.Info("Run 'di down && di up' to restart the infrastructure with the new socket.")
+	} else {
+		ui.Info("Run 'di up' to start the infrastructure with %s.", rt.Name())
+	}
+	return nil
+}
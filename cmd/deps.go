@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/project"
+	"github.com/heysarver/devinfra/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var depsCmd = &cobra.Command{
+	Use:     "deps",
+	Short:   "Manage dependencies between projects",
+	Long:    "Declare that a project needs other projects running first. 'di up' starts dependencies in order and waits for them to be ready.",
+	GroupID: "project",
+}
+
+var depsAddCmd = &cobra.Command{
+	Use:               "add <project> <dependency>...",
+	Short:             "Make a project depend on other projects",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: projectNamesCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return project.AddDependencies(args[0], args[1:])
+	},
+}
+
+var depsRemoveCmd = &cobra.Command{
+	Use:               "remove <project> <dependency>...",
+	Short:             "Remove dependencies from a project",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: projectNamesCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return project.RemoveDependencies(args[0], args[1:])
+	},
+}
+
+var depsListCmd = &cobra.Command{
+	Use:               "list [project]",
+	Short:             "Show the startup order for one or all projects",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: projectNameCompletion,
+	RunE:              runDepsList,
+}
+
+func init() {
+	depsCmd.AddCommand(depsAddCmd)
+	depsCmd.AddCommand(depsRemoveCmd)
+	depsCmd.AddCommand(depsListCmd)
+	rootCmd.AddCommand(depsCmd)
+}
+
+type depsEntry struct {
+	Name       string   `json:"name"`
+	DependsOn  []string `json:"depends_on,omitempty"`
+	Dependents []string `json:"dependents,omitempty"`
+}
+
+func runDepsList(cmd *cobra.Command, args []string) error {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+
+	names := reg.List()
+	if len(args) == 1 {
+		names = args
+	}
+	order, err := reg.StartOrder(names)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]depsEntry, len(order))
+	for i, p := range order {
+		entries[i] = depsEntry{Name: p.Name, DependsOn: p.DependsOn, Dependents: reg.Dependents(p.Name)}
+	}
+
+	if flagJSON {
+		return ui.PrintJSON(entries)
+	}
+
+	headers := []string{"#", "PROJECT", "DEPENDS ON", "NEEDED BY"}
+	var rows [][]string
+	for i, e := range entries {
+		rows = append(rows, []string{fmt.Sprint(i + 1), e.Name, strings.Join(e.DependsOn, ", "), strings.Join(e.Dependents, ", ")})
+	}
+	fmt.Println()
+	ui.PrintTable(headers, rows)
+	fmt.Println()
+	return nil
+}
+
+// projectNamesCompletion completes any number of project names.
+func projectNamesCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return reg.List(), cobra.ShellCompDirectiveNoFileComp
+}
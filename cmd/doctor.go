@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/heysarver/devinfra/internal/config"
 	"github.com/heysarver/devinfra/internal/doctor"
 	"github.com/heysarver/devinfra/internal/ui"
 	"github.com/spf13/cobra"
@@ -20,7 +21,7 @@ func init() {
 
 func runDoctor(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	report := doctor.RunAll(ctx)
+	report := doctor.RunAll(ctx, config.Current())
 
 	if flagJSON {
 		return ui.PrintJSON(report)
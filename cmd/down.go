@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/heysarver/devinfra/internal/compose"
 	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/project"
+	"github.com/heysarver/devinfra/internal/supervisor"
 	"github.com/heysarver/devinfra/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -12,7 +19,7 @@ import (
 var downCmd = &cobra.Command{
 	Use:   "down [project]",
 	Short: "Stop infrastructure or a project",
-	Long:  "Stop core infrastructure (Traefik, DNSMasq, socket-proxy) or a specific project's containers.",
+	Long:  "Stop core infrastructure (Traefik, DNSMasq, socket-proxy) or a specific project's containers and the host service commands di runs for it. With --all or selectors, projects stop concurrently (see --parallel), dependents before their dependencies.",
 	GroupID: "infra",
 	Args:  cobra.MaximumNArgs(1),
 	ValidArgsFunction: projectNameCompletion,
@@ -21,29 +28,52 @@ var downCmd = &cobra.Command{
 
 func init() {
 	downCmd.Flags().BoolVar(&flagAll, "all", false, "stop all registered projects")
+	downCmd.Flags().IntVar(&flagParallel, "parallel", project.DefaultParallel, "how many projects to stop at once with --all or selectors")
+	addSelectorFlags(downCmd)
 	rootCmd.AddCommand(downCmd)
 }
 
 func runDown(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
-	if flagAll {
+	if flagAll || !projectFilter().IsZero() {
+		projects, err := groupProjects(args)
+		if err != nil {
+			return err
+		}
 		reg, err := config.LoadRegistry()
 		if err != nil {
 			return err
 		}
 
-		for _, p := range reg.Projects {
-			if p.HostMode {
+		names := make([]string, len(projects))
+		for i, p := range projects {
+			names[i] = p.Name
+		}
+		if err := reg.CheckCycles(); err != nil {
+			return err
+		}
+		warnRunningDependents(ctx, reg, names)
+
+		// Stop dependents before the projects they depend on. A failure
+		// doesn't hold back the rest.
+		var tasks []project.Task
+		for _, p := range projects {
+			if _, ok := supervisor.Running(p.Name); !ok && !hasContainers(p) {
 				continue
 			}
-			ui.Info("Stopping %s...", p.Name)
-			files := p.ComposeFiles()
-			if err := compose.ProjectDown(ctx, p.Name, p.Dir, files); err != nil {
-				ui.Warn("Failed to stop %s: %v", p.Name, err)
-			} else {
-				ui.Ok("Stopped %s", p.Name)
-			}
+			tasks = append(tasks, project.Task{
+				Name:  p.Name,
+				After: reg.Dependents(p.Name),
+				Run: func(ctx context.Context, out io.Writer) error {
+					return stopProject(ctx, out, p)
+				},
+			})
+		}
+		results := project.Executor{Parallel: flagParallel, KeepGoing: true}.Run(ctx, tasks)
+		project.PrintSummary(results)
+		if failed := project.Failed(results); len(failed) > 0 {
+			return fmt.Errorf("%d of %d projects did not stop: %s", len(failed), len(results), strings.Join(failed, ", "))
 		}
 		return nil
 	}
@@ -67,11 +97,57 @@ func runDown(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("project %q not found in registry", name)
 	}
 
-	ui.Info("Stopping %s...", name)
-	files := p.ComposeFiles()
-	if err := compose.ProjectDown(ctx, p.Name, p.Dir, files); err != nil {
+	if err := stopProject(ctx, os.Stderr, *p); err != nil {
 		return fmt.Errorf("stopping %s: %w", name, err)
 	}
-	ui.Ok("Stopped %s", name)
+	warnRunningDependents(ctx, reg, []string{name})
+	procs, _ := supervisor.Processes(*p)
+	var unmanaged []string
+	for _, svc := range p.HostServices() {
+		if !slices.ContainsFunc(procs, func(proc supervisor.Process) bool { return proc.Name == svc.Name }) {
+			unmanaged = append(unmanaged, svc.Name)
+		}
+	}
+	if len(unmanaged) > 0 {
+		ui.Info("Host services without a command are not managed by di and keep running: %s", strings.Join(unmanaged, ", "))
+	}
 	return nil
 }
+
+// stopProject stops p's containers and the supervisor of its host processes.
+func stopProject(ctx context.Context, out io.Writer, p config.Project) error {
+	ui.InfoTo(out, "Stopping %s...", p.Name)
+	if hasContainers(p) {
+		if err := compose.ProjectDown(ctx, p.Name, p.Dir, p.ComposeFiles()); err != nil {
+			return err
+		}
+	}
+	if _, ok := supervisor.Running(p.Name); ok {
+		if err := supervisor.Stop(p.Name); err != nil {
+			return err
+		}
+		ui.OkTo(out, "Stopped host processes of %s", p.Name)
+	}
+	ui.OkTo(out, "Stopped %s", p.Name)
+	return nil
+}
+
+// warnRunningDependents warns about running projects that depend on any of
+// the stopped ones but are not being stopped themselves.
+func warnRunningDependents(ctx context.Context, reg *config.Registry, stopped []string) {
+	running, err := compose.RunningContainers(ctx)
+	if err != nil {
+		return
+	}
+	var still []string
+	for _, name := range stopped {
+		for _, dep := range reg.Dependents(name) {
+			if len(running[dep]) > 0 && !slices.Contains(stopped, dep) && !slices.Contains(still, dep) {
+				still = append(still, dep)
+			}
+		}
+	}
+	if len(still) > 0 {
+		ui.Warn("Still running and depending on %s: %s", strings.Join(stopped, ", "), strings.Join(still, ", "))
+	}
+}
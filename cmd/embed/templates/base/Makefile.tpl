@@ -1,10 +1,9 @@
 PROJECT_NAME := {{.ProjectName}}
 
-# Auto-discover all compose files: base + any flavor overlays
-# $(sort) ensures deterministic, alphabetical ordering
-# $(wildcard) is Make-native (no shell dependency)
-COMPOSE_FILES := $(sort $(wildcard docker-compose*.yaml))
-COMPOSE_FLAGS := $(patsubst %,-f %,$(COMPOSE_FILES))
+# Delegate to devinfra, which knows the project's exact compose file list
+# (base, overrides, devinfra overlay, and registered flavors) and profiles
+DI ?= di
+COMPOSE := $(DI) compose $(PROJECT_NAME) --
 
 .PHONY: help up down restart logs ps
 
@@ -13,16 +12,16 @@ help: ## Show available commands
 		awk 'BEGIN {FS = ":.*?## "}; {printf "\033[36m%-15s\033[0m %s\n", $$1, $$2}'
 
 up: ## Start this project
-	docker compose $(COMPOSE_FLAGS) up -d
+	$(DI) up $(PROJECT_NAME)
 
 down: ## Stop this project
-	docker compose $(COMPOSE_FLAGS) down
+	$(DI) down $(PROJECT_NAME)
 
 restart: ## Restart this project
-	docker compose $(COMPOSE_FLAGS) restart
+	$(COMPOSE) restart
 
 logs: ## Tail logs
-	docker compose $(COMPOSE_FLAGS) logs -f
+	$(DI) logs $(PROJECT_NAME)
 
 ps: ## Show running containers
-	docker compose $(COMPOSE_FLAGS) ps
+	$(COMPOSE) ps
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagExecNoTTY bool
+	flagExecUser  string
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <project> <service> [-- command...]",
+	Short: "Run a command in a running project container",
+	Long:  "Run a command in the running container of a project's service, with the project's full compose file list. Without a command, opens a shell (sh).\n\nA TTY is allocated when stdin and stdout are terminals, so output can be piped; --no-tty disables it. di exits with the command's exit code.",
+	Example: `  di exec myapp web
+  di exec myapp web -- rails console
+  di exec -T myapp postgres -- pg_dump app > dump.sql`,
+	GroupID:           "project",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: serviceArgsCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := containerService(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		return passExitCode(compose.ProjectExec(cmd.Context(), p.Name, p.Dir, p.ComposeFiles(), args[1], commandArgs(args), execOptions()))
+	},
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <project> <service> [-- command...]",
+	Short: "Run a command in a one-off project container",
+	Long:  "Run a command in a new container of a project's service, removed when it exits, with the project's full compose file list. Without a command, runs the service's default command.\n\nA TTY is allocated when stdin and stdout are terminals, so output can be piped; --no-tty disables it. di exits with the command's exit code.",
+	Example: `  di run myapp web -- bundle exec rake db:migrate
+  di run -T myapp web -- npm test`,
+	GroupID:           "project",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: serviceArgsCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := containerService(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		return passExitCode(compose.ProjectRun(cmd.Context(), p.Name, p.Dir, p.ComposeFiles(), args[1], commandArgs(args), execOptions()))
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{execCmd, runCmd} {
+		c.Flags().BoolVarP(&flagExecNoTTY, "no-tty", "T", false, "don't allocate a TTY")
+		c.Flags().StringVarP(&flagExecUser, "user", "u", "", "run the command as this user")
+		// Flags after the service belong to the command
+		c.Flags().SetInterspersed(false)
+		rootCmd.AddCommand(c)
+	}
+}
+
+// commandArgs returns the command after the project and service, dropping
+// the optional "--" separator.
+func commandArgs(args []string) []string {
+	command := args[2:]
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+	return command
+}
+
+func execOptions() compose.ExecOptions {
+	return compose.ExecOptions{
+		TTY:  !flagExecNoTTY && isTerminal(os.Stdin) && isTerminal(os.Stdout),
+		User: flagExecUser,
+	}
+}
+
+// containerService returns the named project after checking that service is
+// one of its compose services rather than a host service.
+func containerService(name, service string) (*config.Project, error) {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return nil, err
+	}
+	p := reg.Get(name)
+	if p == nil {
+		return nil, fmt.Errorf("project %q not found in registry", name)
+	}
+	for _, svc := range p.HostServices() {
+		if svc.Name == service {
+			return nil, fmt.Errorf("%s runs on the host, not in a container", service)
+		}
+	}
+	// Leave unreadable compose files for compose itself to report
+	if services, err := composeServiceNames(p); err == nil && !slices.Contains(services, service) {
+		return nil, fmt.Errorf("service %q not found in %s; services: %s", service, p.Name, strings.Join(services, ", "))
+	}
+	return p, nil
+}
+
+// composeServiceNames returns the services of the project's compose model,
+// including flavor services, sorted by name.
+func composeServiceNames(p *config.Project) ([]string, error) {
+	detected, err := compose.ParseServices(p.Dir, p.ComposeFiles())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(detected))
+	for i, d := range detected {
+		names[i] = d.Name
+	}
+	return names, nil
+}
+
+// serviceArgsCompletion completes a project name, then one of its compose
+// services.
+func serviceArgsCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return projectNameCompletion(cmd, args, toComplete)
+	case 1:
+		reg, err := config.LoadRegistry()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		p := reg.Get(args[0])
+		if p == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		services, _ := composeServiceNames(p)
+		return services, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// exitCodeError makes di exit with a command's exit code without printing
+// an error, for commands whose output the user has already seen.
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// passExitCode turns the non-zero exit of an attached command into an
+// exitCodeError.
+func passExitCode(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return &exitCodeError{code: exitErr.ExitCode()}
+	}
+	return err
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
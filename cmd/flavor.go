@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/charmbracelet/huh"
+	"github.com/heysarver/devinfra/internal/config"
 	"github.com/heysarver/devinfra/internal/project"
 	"github.com/heysarver/devinfra/internal/ui"
 	"github.com/spf13/cobra"
@@ -99,5 +100,5 @@ func runFlavorAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	project.TemplatesFS = embeddedTemplatesFS
-	return project.AddFlavor(args[0], args[1])
+	return project.AddFlavor(config.Current(), args[0], args[1])
 }
@@ -118,9 +118,12 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("creating directories: %w", err)
 	}
 
+	// The chosen TLD takes precedence over the environment and .env, like a flag.
+	settings, _ := config.Load(map[string]string{"tld": tld})
+
 	// Extract embedded compose files (rendered with chosen TLD)
 	ui.Info("Extracting embedded resources...")
-	if err := compose.ExtractEmbedded(tld); err != nil {
+	if err := compose.ExtractEmbedded(settings); err != nil {
 		return fmt.Errorf("extracting embedded files: %w", err)
 	}
 
@@ -139,7 +142,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 			ui.Warn("Platform %s not supported for automatic setup. Use --skip-platform.", platform)
 		} else {
 			ui.Info("Running platform setup (%s)...", platform)
-			scriptPath, err := compose.ExtractSetupScript(platform, tld)
+			scriptPath, err := compose.ExtractSetupScript(platform, settings)
 			if err != nil {
 				return fmt.Errorf("extracting setup script: %w", err)
 			}
@@ -168,7 +171,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	// Generate infrastructure certs
 	ui.Info("Generating infrastructure certificates...")
-	if err := compose.GenerateInfraCerts(ctx); err != nil {
+	if err := compose.GenerateInfraCerts(ctx, settings); err != nil {
 		ui.Warn("Could not generate infra certs: %v", err)
 		ui.Warn("Ensure mkcert is installed and run 'di init' again.")
 	}
@@ -255,7 +258,9 @@ func importFrom(srcDir string) error {
 		if err != nil {
 			return fmt.Errorf("reading source registry: %w", err)
 		}
-		if err := os.WriteFile(config.RegistryPath(), data, 0644); err != nil {
+		if err := config.WithLock(func() error {
+			return os.WriteFile(config.RegistryPath(), data, 0644)
+		}); err != nil {
 			return fmt.Errorf("writing registry: %w", err)
 		}
 		ui.Ok("Imported projects.yaml")
@@ -6,20 +6,27 @@ import (
 
 	"github.com/heysarver/devinfra/internal/compose"
 	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/project"
+	"github.com/heysarver/devinfra/internal/supervisor"
 	"github.com/heysarver/devinfra/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 type inspectOutput struct {
-	Name     string           `json:"name"`
-	Dir      string           `json:"dir"`
-	Domain   string           `json:"domain"`
-	Mode     string           `json:"mode"`
-	Status   string           `json:"status"`
-	Services []config.Service `json:"services"`
-	Flavors  []string         `json:"flavors,omitempty"`
-	URLs     []string         `json:"urls"`
-	Created  string           `json:"created_at"`
+	Name     string             `json:"name"`
+	Dir      string             `json:"dir"`
+	Domain   string             `json:"domain"`
+	Extra    []string           `json:"extra_domains,omitempty"`
+	Mode     string             `json:"mode"`
+	Status   string             `json:"status"`
+	Services []config.Service   `json:"services"`
+	Flavors  []string           `json:"flavors,omitempty"`
+	Tags     []string           `json:"tags,omitempty"`
+	Depends  []string           `json:"depends_on,omitempty"`
+	Profiles []string           `json:"profiles,omitempty"`
+	URLs     []string           `json:"urls"`
+	TCP      []project.TCPRoute `json:"tcp_endpoints,omitempty"`
+	Created  string             `json:"created_at"`
 }
 
 var inspectCmd = &cobra.Command{
@@ -61,10 +68,11 @@ func runInspect(cmd *cobra.Command, args []string) error {
 	}
 
 	// Determine status
-	mode := "docker"
+	mode := p.Mode()
 	status := "stopped"
-	if p.HostMode {
-		mode = "host"
+	if _, ok := supervisor.Running(name); ok && p.HostMode {
+		status = "running"
+	} else if p.HostMode {
 		status = "host"
 	} else {
 		running, _ := compose.RunningContainers(ctx)
@@ -73,24 +81,20 @@ func runInspect(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Build URLs
-	tld := config.TLD()
-	urls := []string{fmt.Sprintf("https://%s.%s", name, tld)}
-	for i, svc := range p.Services {
-		if i > 0 {
-			urls = append(urls, fmt.Sprintf("https://%s.%s.%s", svc.Name, name, tld))
-		}
-	}
-
 	out := inspectOutput{
 		Name:     p.Name,
 		Dir:      p.Dir,
 		Domain:   p.Domain,
+		Extra:    p.ExtraDomains,
 		Mode:     mode,
 		Status:   status,
 		Services: p.Services,
 		Flavors:  p.Flavors,
-		URLs:     urls,
+		Tags:     p.Tags,
+		Depends:  p.DependsOn,
+		Profiles: p.Profiles,
+		URLs:     p.URLs(),
+		TCP:      project.TCPRoutes(config.Current(), *p),
 		Created:  p.Created,
 	}
 
@@ -102,21 +106,58 @@ func runInspect(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Name:      %s\n", out.Name)
 	fmt.Printf("Directory: %s\n", out.Dir)
 	fmt.Printf("Domain:    %s\n", out.Domain)
+	if len(out.Extra) > 0 {
+		fmt.Printf("Extra:     %s\n", strings.Join(out.Extra, ", "))
+	}
 	fmt.Printf("Mode:      %s\n", out.Mode)
 	fmt.Printf("Status:    %s\n", out.Status)
 	fmt.Printf("Created:   %s\n", out.Created)
 	fmt.Println()
 	fmt.Println("Services:")
 	for _, svc := range out.Services {
-		fmt.Printf("  %s:%d\n", svc.Name, svc.Port)
+		line := fmt.Sprintf("  %s:%d", svc.Name, svc.Port)
+		if svc.Subdomain != "" {
+			line += fmt.Sprintf(" → %s", svc.Subdomain)
+		}
+		if p.HostMode || svc.Host {
+			line += " (host)"
+		}
+		if svc.Command != "" {
+			line += fmt.Sprintf(" $ %s", svc.Command)
+			if svc.Workdir != "" {
+				line += fmt.Sprintf(" (in %s)", svc.Workdir)
+			}
+		}
+		switch svc.Routing {
+		case config.RoutingAdopt:
+			line += " (adopts existing traefik routers)"
+		case config.RoutingSkip:
+			line += " (existing traefik routers, not rewritten)"
+		}
+		fmt.Println(line)
 	}
 	if len(out.Flavors) > 0 {
 		fmt.Printf("\nFlavors:   %s\n", strings.Join(out.Flavors, ", "))
 	}
+	if len(out.Tags) > 0 {
+		fmt.Printf("Tags:      %s\n", strings.Join(out.Tags, ", "))
+	}
+	if len(out.Profiles) > 0 {
+		fmt.Printf("Profiles:  %s\n", strings.Join(out.Profiles, ", "))
+	}
+	if len(out.Depends) > 0 {
+		fmt.Printf("Depends:   %s\n", strings.Join(out.Depends, ", "))
+	}
 	fmt.Printf("\nURLs:\n")
 	for _, u := range out.URLs {
 		fmt.Printf("  %s\n", u)
 	}
+	if len(out.TCP) > 0 {
+		fmt.Printf("\nTCP endpoints (TLS, SNI):\n")
+		for _, r := range out.TCP {
+			fmt.Printf("  %-10s %s:%d\n", r.Service, r.Host, r.Port)
+		}
+	}
 
 	return nil
 }
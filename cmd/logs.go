@@ -1,43 +1,224 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/heysarver/devinfra/internal/compose"
 	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/supervisor"
+	"github.com/heysarver/devinfra/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	flagLogsServices []string
+	flagLogsSince    string
+	flagLogsTail     string
+	flagLogsGrep     string
+	flagLogsNoFollow bool
+)
+
 var logsCmd = &cobra.Command{
-	Use:   "logs [project]",
+	Use:   "logs [project...]",
 	Short: "Tail infrastructure or project logs",
+	Long:  "Tail core infrastructure logs, or the logs of one or more projects.\n\nLogs of several projects (or of projects selected with --tag/--selector) are merged into one stream with each line prefixed by project/service. The output of host service commands di runs (see 'di up') is included next to the containers'. --grep keeps only matching lines, and --json prints each line as a JSON object with its project, service, and timestamp.",
+	Example: `  di logs myapp
+  di logs frontend api auth --service web --since 10m
+  di logs --tag billing --grep 'request_id=abc123'
+  di logs myapp --json --no-follow --tail 100`,
 	GroupID: "infra",
-	Args:  cobra.MaximumNArgs(1),
-	ValidArgsFunction: projectNameCompletion,
+	Args:  cobra.ArbitraryArgs,
+	ValidArgsFunction: projectNamesCompletion,
 	RunE:  runLogs,
 }
 
 func init() {
+	logsCmd.Flags().StringSliceVar(&flagLogsServices, "service", nil, "only this service (repeatable)")
+	logsCmd.Flags().StringVar(&flagLogsSince, "since", "", "show logs since a duration ago (e.g. 10m) or a timestamp")
+	logsCmd.Flags().StringVar(&flagLogsTail, "tail", "", "number of lines to show from the end of each container's log")
+	logsCmd.Flags().StringVar(&flagLogsGrep, "grep", "", "only lines matching this regular expression")
+	logsCmd.Flags().BoolVar(&flagLogsNoFollow, "no-follow", false, "print existing logs and exit instead of following")
+	addSelectorFlags(logsCmd)
 	rootCmd.AddCommand(logsCmd)
 }
 
 func runLogs(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
+	opts := compose.LogOptions{
+		Services: flagLogsServices,
+		Since:    flagLogsSince,
+		Tail:     flagLogsTail,
+		NoFollow: flagLogsNoFollow,
+	}
 
-	if len(args) == 0 {
+	var projects []config.Project
+	if !projectFilter().IsZero() {
+		selected, err := selectedProjects(args)
+		if err != nil {
+			return err
+		}
+		projects = selected
+	} else if len(args) == 0 {
 		return compose.Logs(ctx)
+	} else {
+		reg, err := config.LoadRegistry()
+		if err != nil {
+			return err
+		}
+		for _, name := range args {
+			p := reg.Get(name)
+			if p == nil {
+				return fmt.Errorf("project %q not found in registry", name)
+			}
+			projects = append(projects, *p)
+		}
+	}
+
+	// A single project without filtering or host processes is compose's own output
+	if len(projects) == 1 && projectFilter().IsZero() && flagLogsGrep == "" && !flagJSON && hasContainers(projects[0]) && !supervised(projects[0]) {
+		p := projects[0]
+		return compose.ProjectLogs(ctx, p.Name, p.Dir, p.ComposeFiles(), opts)
+	}
+	return mergedLogs(cmd, projects, opts)
+}
+
+// logStream is one project service whose logs are merged.
+type logStream struct {
+	project config.Project
+	service string
+	logPath string // log file of a host process di runs; "" for containers
+}
+
+// logLine is a log line in --json output.
+type logLine struct {
+	Project string `json:"project"`
+	Service string `json:"service"`
+	Time    string `json:"time,omitempty"`
+	Message string `json:"message"`
+}
+
+// mergedLogs follows the logs of every selected service of several projects
+// at once, prefixing each line with project/service.
+func mergedLogs(cmd *cobra.Command, projects []config.Project, opts compose.LogOptions) error {
+	ctx := cmd.Context()
+
+	var grep *regexp.Regexp
+	if flagLogsGrep != "" {
+		var err error
+		if grep, err = regexp.Compile(flagLogsGrep); err != nil {
+			return fmt.Errorf("invalid --grep pattern: %w", err)
+		}
 	}
 
-	name := args[0]
-	reg, err := config.LoadRegistry()
+	streams, err := logStreams(projects, opts.Services)
 	if err != nil {
 		return err
 	}
-	p := reg.Get(name)
-	if p == nil {
-		return fmt.Errorf("project %q not found in registry", name)
+	width := 0
+	for _, s := range streams {
+		width = max(width, len(s.project.Name)+1+len(s.service))
 	}
 
-	files := p.ComposeFiles()
-	return compose.ProjectLogs(ctx, p.Name, p.Dir, files)
+	var mu sync.Mutex
+	enc := json.NewEncoder(os.Stdout)
+	var wg sync.WaitGroup
+	for _, s := range streams {
+		label := s.project.Name + "/" + s.service
+		prefix := ui.Label(label, fmt.Sprintf("%-*s |", width, label)) + " "
+		stdout := ui.NewLineWriter(func(line string) {
+			ts, msg := splitTimestamp(line)
+			if grep != nil && !grep.MatchString(msg) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if flagJSON {
+				_ = enc.Encode(logLine{Project: s.project.Name, Service: s.service, Time: ts, Message: msg})
+				return
+			}
+			fmt.Fprintln(os.Stdout, prefix+msg)
+		})
+		stderr := ui.NewPrefixWriter(&mu, os.Stderr, prefix)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := s.project
+			var err error
+			if s.logPath != "" {
+				err = supervisor.FollowLog(ctx, s.logPath, opts, stdout)
+			} else {
+				err = compose.ServiceLogsTo(ctx, p.Name, p.Dir, p.ComposeFiles(), s.service, opts, stdout, stderr)
+			}
+			if err != nil && ctx.Err() == nil {
+				ui.Warn("Logs for %s ended: %v", label, err)
+			}
+			stdout.Flush()
+			stderr.Flush()
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// logStreams lists the compose services and supervised host processes of
+// each project to follow, restricted to services when given.
+func logStreams(projects []config.Project, services []string) ([]logStream, error) {
+	var streams []logStream
+	matched := make(map[string]bool)
+	add := func(s logStream) {
+		if len(services) > 0 && !slices.Contains(services, s.service) {
+			return
+		}
+		matched[s.service] = true
+		streams = append(streams, s)
+	}
+	for _, p := range projects {
+		if hasContainers(p) {
+			names, err := composeServiceNames(&p)
+			if err != nil {
+				ui.Warn("Skipping containers of %s: %v", p.Name, err)
+			}
+			for _, name := range names {
+				add(logStream{project: p, service: name})
+			}
+		}
+		procs, err := supervisor.Processes(p)
+		if err != nil {
+			ui.Warn("Skipping host processes of %s: %v", p.Name, err)
+		}
+		for _, proc := range procs {
+			add(logStream{project: p, service: proc.Name, logPath: supervisor.LogPath(p.Name, proc.Name)})
+		}
+	}
+	for _, name := range services {
+		if !matched[name] {
+			return nil, fmt.Errorf("no selected project has a service named %q", name)
+		}
+	}
+	if len(streams) == 0 {
+		return nil, fmt.Errorf("no services to show logs for")
+	}
+	return streams, nil
+}
+
+// splitTimestamp splits the RFC 3339 timestamp that compose prepends with
+// --timestamps, as does the host process supervisor, from the rest of a log
+// line.
+func splitTimestamp(line string) (string, string) {
+	ts, msg, ok := strings.Cut(line, " ")
+	if !ok {
+		return "", line
+	}
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		return "", line
+	}
+	return ts, msg
 }
@@ -100,6 +100,7 @@ func runNew(cmd *cobra.Command, args []string) error {
 			HostMode: result.HostMode,
 			Services: result.Services,
 			Flavors:  result.Flavors,
+			Settings: config.Current(),
 		}
 		applyPreset(&opts, flagNewType)
 		return project.Create(ctx, opts)
@@ -178,6 +179,7 @@ func runNew(cmd *cobra.Command, args []string) error {
 		HostMode: hostMode,
 		Services: services,
 		Flavors:  flavors,
+		Settings: config.Current(),
 	}
 	applyPreset(&opts, flagNewType)
 	return project.Create(ctx, opts)
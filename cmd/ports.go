@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// infraProject is the compose project name of the core infrastructure.
+const infraProject = "devinfra"
+
+// portRow is one host port allocation in `di ports`.
+type portRow struct {
+	Port       int      `json:"port"`
+	Protocol   string   `json:"protocol"`
+	Project    string   `json:"project"`
+	Service    string   `json:"service"`
+	Target     int      `json:"target,omitempty"` // container port; 0 for host services
+	Host       bool     `json:"host,omitempty"`   // a host service listening on the port itself
+	Status     string   `json:"status"`           // free, bound, in use, listening, not listening
+	Owner      string   `json:"owner,omitempty"`
+	SharedWith []string `json:"shared_with,omitempty"` // other projects claiming the same port
+}
+
+var portsCmd = &cobra.Command{
+	Use:   "ports [project...]",
+	Short: "List host port allocations across projects",
+	Long:  "List every host port published by the core infrastructure and registered projects (all compose profiles included), plus the ports host services listen on, with what currently holds each one.\n\nStatus is free, bound (held by the project itself), or in use by another container or process. Ports claimed by more than one project are flagged; they cannot run at the same time.",
+	GroupID:           "project",
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: projectNamesCompletion,
+	RunE:              runPorts,
+}
+
+func init() {
+	rootCmd.AddCommand(portsCmd)
+}
+
+func runPorts(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+	projects := reg.Projects
+	if len(args) > 0 {
+		projects = nil
+		for _, name := range args {
+			p := reg.Get(name)
+			if p == nil {
+				return fmt.Errorf("project %q not found in registry", name)
+			}
+			projects = append(projects, *p)
+		}
+	}
+
+	var rows []portRow
+	if len(args) == 0 {
+		ports, err := compose.InfraPorts()
+		if err != nil {
+			ui.Warn("Could not read infrastructure ports: %v", err)
+		}
+		rows = append(rows, publishedRows(infraProject, ports)...)
+	}
+	for _, p := range projects {
+		if len(p.DockerServices()) > 0 || len(p.Flavors) > 0 {
+			files := p.ComposeFiles()
+			profiles, _ := compose.Profiles(p.Dir, files)
+			ports, err := compose.ProjectPorts(p.Dir, files, profiles)
+			if err != nil {
+				ui.Warn("Could not read ports of %s: %v", p.Name, err)
+			}
+			rows = append(rows, publishedRows(p.Name, ports)...)
+		}
+		for _, svc := range p.HostServices() {
+			rows = append(rows, portRow{Port: svc.Port, Protocol: "tcp", Project: p.Name, Service: svc.Name, Host: true})
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b portRow) int {
+		return cmp.Or(a.Port-b.Port, strings.Compare(a.Protocol, b.Protocol))
+	})
+
+	bindings, err := compose.CurrentRuntime().PortBindings(ctx)
+	if err != nil {
+		ui.Warn("Could not query container ports: %v", err)
+	}
+	for i := range rows {
+		r := &rows[i]
+		p := compose.PublishedPort{Port: r.Port, Protocol: r.Protocol}
+		for _, other := range rows {
+			if other.Port == r.Port && other.Protocol == r.Protocol && other.Project != r.Project && !slices.Contains(r.SharedWith, other.Project) {
+				r.SharedWith = append(r.SharedWith, other.Project)
+			}
+		}
+
+		inUse := compose.PortInUse(p)
+		switch {
+		case r.Host && inUse:
+			r.Status = "listening"
+			r.Owner, _ = compose.PortOwner(ctx, p, nil)
+		case r.Host:
+			r.Status = "not listening"
+		case !inUse:
+			r.Status = "free"
+		default:
+			var ownerProject string
+			r.Owner, ownerProject = compose.PortOwner(ctx, p, bindings)
+			r.Status = "in use"
+			if ownerProject == r.Project {
+				r.Status = "bound"
+			}
+		}
+	}
+
+	if flagJSON {
+		return ui.PrintJSON(rows)
+	}
+	if len(rows) == 0 {
+		ui.Info("No host ports are published.")
+		return nil
+	}
+
+	tableRows := make([][]string, len(rows))
+	for i, r := range rows {
+		target := "host"
+		if !r.Host {
+			target = strconv.Itoa(r.Target)
+		}
+		status := r.Status
+		if r.Status == "in use" {
+			status = "in use by " + cmp.Or(r.Owner, "another process")
+		}
+		if len(r.SharedWith) > 0 {
+			status += "; also claimed by " + strings.Join(r.SharedWith, ", ")
+		}
+		tableRows[i] = []string{strconv.Itoa(r.Port), r.Protocol, r.Project, r.Service, target, status}
+	}
+	fmt.Println()
+	ui.PrintTable([]string{"PORT", "PROTO", "PROJECT", "SERVICE", "TARGET", "STATUS"}, tableRows)
+	fmt.Println()
+	return nil
+}
+
+func publishedRows(project string, ports []compose.PublishedPort) []portRow {
+	rows := make([]portRow, len(ports))
+	for i, p := range ports {
+		rows[i] = portRow{Port: p.Port, Protocol: p.Protocol, Project: project, Service: p.Service, Target: p.Target}
+	}
+	return rows
+}
+
+// checkProjectPorts fails if a host port p publishes is held by anything
+// but p itself.
+func checkProjectPorts(ctx context.Context, out io.Writer, p config.Project) error {
+	ports, err := compose.ProjectPorts(p.Dir, p.ComposeFiles(), p.Profiles)
+	if err != nil {
+		ui.WarnTo(out, "Could not read ports of %s: %v", p.Name, err)
+		return nil
+	}
+	return checkPorts(ctx, p.Name, p.Name, ports)
+}
+
+// checkPorts fails if any of ports is held by anything but the compose
+// project, naming each port's owner.
+func checkPorts(ctx context.Context, project, what string, ports []compose.PublishedPort) error {
+	conflicts := compose.PortConflicts(ctx, project, ports)
+	if len(conflicts) == 0 {
+		return nil
+	}
+	lines := make([]string, len(conflicts))
+	for i, c := range conflicts {
+		lines[i] = "  " + c.String()
+	}
+	return fmt.Errorf("cannot start %s, host ports are taken:\n%s\nfree them or change the published ports ('di ports' lists all allocations)", what, strings.Join(lines, "\n"))
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/project"
+	"github.com/heysarver/devinfra/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage a project's default compose profiles",
+	Long: `Choose which compose profiles 'di up' enables for a project. Services in
+other profiles don't start and get no Traefik routes. 'di up <project>
+--profile <name>' overrides the defaults for a single run.`,
+	GroupID: "project",
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:               "add <project> <profile>...",
+	Short:             "Enable compose profiles by default",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: profileArgsCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return project.AddProfiles(config.Current(), args[0], args[1:])
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:               "remove <project> <profile>...",
+	Short:             "Stop enabling compose profiles by default",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: profileArgsCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return project.RemoveProfiles(config.Current(), args[0], args[1:])
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:               "list [project]",
+	Short:             "List declared, default, and active profiles",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: projectNameCompletion,
+	RunE:              runProfileList,
+}
+
+func init() {
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+	profileCmd.AddCommand(profileListCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+type profileStatus struct {
+	Project  string   `json:"project"`
+	Declared []string `json:"declared"`
+	Default  []string `json:"default"`
+	Active   []string `json:"active"`
+}
+
+func runProfileList(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+	projects := reg.Projects
+	if len(args) == 1 {
+		p := reg.Get(args[0])
+		if p == nil {
+			return fmt.Errorf("project %q not found in registry", args[0])
+		}
+		projects = []config.Project{*p}
+	}
+
+	var out []profileStatus
+	for _, p := range projects {
+		if p.HostMode {
+			continue
+		}
+		files := p.ComposeFiles()
+		declared, err := compose.Profiles(p.Dir, files)
+		if err != nil {
+			ui.Warn("Could not read compose files for %s: %v", p.Name, err)
+			continue
+		}
+		if len(declared) == 0 && len(p.Profiles) == 0 && len(args) == 0 {
+			continue
+		}
+		active, _ := compose.ActiveProfiles(ctx, p.Name, p.Dir, files)
+		out = append(out, profileStatus{Project: p.Name, Declared: declared, Default: p.Profiles, Active: active})
+	}
+
+	if flagJSON {
+		return ui.PrintJSON(out)
+	}
+	if len(out) == 0 {
+		ui.Info("No project uses compose profiles.")
+		return nil
+	}
+
+	headers := []string{"PROJECT", "DECLARED", "DEFAULT", "ACTIVE"}
+	var rows [][]string
+	for _, s := range out {
+		rows = append(rows, []string{s.Project, joinOrDash(s.Declared), joinOrDash(s.Default), joinOrDash(s.Active)})
+	}
+	fmt.Println()
+	ui.PrintTable(headers, rows)
+	fmt.Println()
+	return nil
+}
+
+func joinOrDash(list []string) string {
+	if len(list) == 0 {
+		return "-"
+	}
+	return strings.Join(list, ", ")
+}
+
+// profileArgsCompletion completes the project name first, then the profiles
+// its compose files declare.
+func profileArgsCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return projectNameCompletion(cmd, args, toComplete)
+	}
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	p := reg.Get(args[0])
+	if p == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	profiles, _ := compose.Profiles(p.Dir, p.ComposeFiles())
+	return profiles, cobra.ShellCompDirectiveNoFileComp
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/heysarver/devinfra/internal/config"
 	"github.com/heysarver/devinfra/internal/project"
 	"github.com/spf13/cobra"
 )
@@ -15,9 +16,22 @@ var regenerateCmd = &cobra.Command{
 }
 
 func init() {
+	addSelectorFlags(regenerateCmd)
 	rootCmd.AddCommand(regenerateCmd)
 }
 
 func runRegenerate(cmd *cobra.Command, args []string) error {
-	return project.RegenerateAll(cmd.Context())
+	if projectFilter().IsZero() {
+		return project.RegenerateAll(cmd.Context(), config.Current())
+	}
+
+	projects, err := selectedProjects(args)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(projects))
+	for i, p := range projects {
+		names[i] = p.Name
+	}
+	return project.Regenerate(cmd.Context(), config.Current(), names)
 }
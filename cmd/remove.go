@@ -83,7 +83,7 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	return project.Remove(ctx, name, flagNoDirectoryPreserve)
+	return project.Remove(ctx, config.Current(), name, flagNoDirectoryPreserve)
 }
 
 func buildRemoveDescription(name string, p *config.Project, noDirectoryPreserve bool) string {
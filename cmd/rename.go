@@ -20,7 +20,7 @@ var renameCmd = &cobra.Command{
 	Long: `Update a project's name in the registry and/or change the directory it tracks.
 
 When renaming:
-  - Running containers are stopped
+  - Running containers and host processes are stopped
   - Old certificates are removed and new ones are generated
   - The registry is updated with the new name and domain
 
@@ -125,9 +125,10 @@ func runRename(cmd *cobra.Command, args []string) error {
 	}
 
 	return project.Rename(ctx, project.RenameOpts{
-		OldName: oldName,
-		NewName: newName,
-		NewDir:  flagRenameDir,
+		OldName:  oldName,
+		NewName:  newName,
+		NewDir:   flagRenameDir,
+		Settings: config.Current(),
 	})
 }
 
@@ -137,7 +138,7 @@ func buildRenameDescription(oldName, newName string, nameChanged, dirChanged boo
 	if nameChanged {
 		tld := config.TLD()
 		lines = append(lines,
-			"  - Stop running containers",
+			"  - Stop running containers and host processes",
 			fmt.Sprintf("  - Delete certs for *.%s.%s", oldName, tld),
 			fmt.Sprintf("  - Generate new certs for *.%s.%s", newName, tld),
 			fmt.Sprintf("  - Update registry: name '%s' → '%s'", oldName, newName),
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/heysarver/devinfra/internal/config"
@@ -33,6 +35,14 @@ var rootCmd = &cobra.Command{
 			_ = os.Setenv("DEVINFRA_HOME", flagConfigDir)
 		}
 
+		// Resolve settings once per invocation: a flag named after the key
+		// (init's --tld), then environment, then .env, then defaults. Config
+		// and diagnostic commands still run with invalid values (and report
+		// them) so they can be inspected and fixed.
+		if _, err := config.Load(settingFlags(cmd)); err != nil && !tolerateInvalidConfig(cmd) {
+			return fmt.Errorf("%w\nRun 'di config list' to review, then 'di config set' or 'di config unset' to fix", err)
+		}
+
 		// Skip init check for commands that don't need config
 		skip := map[string]bool{
 			"init":       true,
@@ -55,8 +65,37 @@ var rootCmd = &cobra.Command{
 	SilenceErrors: true,
 }
 
+// settingFlags collects configuration overrides from flags named after a
+// config key, with dots replaced by dashes (e.g. --tld, --dns-port).
+func settingFlags(cmd *cobra.Command) map[string]string {
+	flags := map[string]string{}
+	for _, k := range config.Keys {
+		f := cmd.Flags().Lookup(strings.ReplaceAll(k.Name, ".", "-"))
+		if f != nil && f.Changed {
+			flags[k.Name] = f.Value.String()
+		}
+	}
+	return flags
+}
+
+// tolerateInvalidConfig reports whether cmd should run despite invalid
+// settings, warning instead of failing.
+func tolerateInvalidConfig(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "config", "doctor", "init", "help", "version", "completion":
+			return true
+		}
+	}
+	return false
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagTags     []string
+	flagSelector string
+)
+
+// addSelectorFlags registers --tag and --selector on a command that can act
+// on a group of projects.
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&flagTags, "tag", nil, "only projects with this tag (repeatable; any tag matches)")
+	cmd.Flags().StringVar(&flagSelector, "selector", "", "comma-separated tags projects must all match; prefix with ! to exclude (e.g. billing,!legacy)")
+	_ = cmd.RegisterFlagCompletionFunc("tag", tagCompletion)
+}
+
+// projectFilter returns the filter described by --tag and --selector.
+func projectFilter() config.ProjectFilter {
+	return config.ProjectFilter{Tags: flagTags, Selector: flagSelector}
+}
+
+// selectedProjects returns the projects matched by --tag/--selector. It is an
+// error to combine them with an explicit project name or to match nothing.
+func selectedProjects(args []string) ([]config.Project, error) {
+	if len(args) > 0 {
+		return nil, fmt.Errorf("--tag and --selector cannot be combined with a project name")
+	}
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return nil, err
+	}
+	projects, err := reg.Filter(projectFilter())
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("no projects match the given tags")
+	}
+	return projects, nil
+}
+
+// groupProjects returns every registered project for --all, otherwise the
+// projects matched by --tag/--selector.
+func groupProjects(args []string) ([]config.Project, error) {
+	if !flagAll {
+		return selectedProjects(args)
+	}
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return reg.Projects, nil
+}
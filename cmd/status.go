@@ -2,16 +2,19 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/heysarver/devinfra/internal/compose"
 	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/supervisor"
 	"github.com/heysarver/devinfra/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 type statusOutput struct {
-	Projects []projectStatus `json:"projects"`
+	Projects []projectStatus     `json:"projects"`
+	Groups   map[string][]string `json:"groups,omitempty"` // tag → project names, with --by-tag
 }
 
 type projectStatus struct {
@@ -21,8 +24,15 @@ type projectStatus struct {
 	URLs     []string `json:"urls"`
 	Services []string `json:"services,omitempty"`
 	Flavors  []string `json:"flavors,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Profiles []string `json:"profiles,omitempty"` // active compose profiles
 }
 
+// untaggedGroup heads the group of projects without tags in `di status --by-tag`.
+const untaggedGroup = "(untagged)"
+
+var flagStatusByTag bool
+
 var statusCmd = &cobra.Command{
 	Use:     "status",
 	Short:   "List all registered projects and their status",
@@ -31,6 +41,8 @@ var statusCmd = &cobra.Command{
 }
 
 func init() {
+	statusCmd.Flags().BoolVar(&flagStatusByTag, "by-tag", false, "group projects by tag")
+	addSelectorFlags(statusCmd)
 	rootCmd.AddCommand(statusCmd)
 }
 
@@ -47,6 +59,11 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	projects, err := reg.Filter(projectFilter())
+	if err != nil {
+		return err
+	}
+
 	// Single docker ps call for all running containers
 	running, err := compose.RunningContainers(ctx)
 	if err != nil {
@@ -55,34 +72,48 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	var output statusOutput
 
-	for _, p := range reg.Projects {
-		mode := "docker"
+	for _, p := range projects {
+		mode := p.Mode()
 		status := "stopped"
 
-		if p.HostMode {
-			mode = "host"
+		if _, ok := supervisor.Running(p.Name); ok && p.HostMode {
+			status = "running"
+		} else if p.HostMode {
 			status = "host"
 		} else if _, ok := running[p.Name]; ok {
 			status = "running"
 		}
+		if mode == "mixed" {
+			hosted := p.HostServices()
+			listening := 0
+			for _, svc := range hosted {
+				if hostPortListening(svc.Port) {
+					listening++
+				}
+			}
+			status = fmt.Sprintf("%s, host %d/%d", status, listening, len(hosted))
+		}
 
 		// Build URLs
-		tld := config.TLD()
 		remote := config.Remote()
-		urls := []string{fmt.Sprintf("https://%s.%s", p.Name, tld)}
-		for _, svc := range p.Services {
-			urls = append(urls, fmt.Sprintf("https://%s.%s.%s", svc.Name, p.Name, tld))
-		}
+		urls := p.URLs()
 		if remote.Enabled {
-			urls = append(urls, fmt.Sprintf("https://%s.%s [remote]", p.Name, remote.Domain))
-			for _, svc := range p.Services {
-				urls = append(urls, fmt.Sprintf("https://%s.%s.%s [remote]", svc.Name, p.Name, remote.Domain))
+			for _, u := range p.URLsFor([]string{fmt.Sprintf("%s.%s", p.Name, remote.Domain)}) {
+				urls = append(urls, u+" [remote]")
 			}
 		}
 
 		svcNames := make([]string, len(p.Services))
 		for i, s := range p.Services {
 			svcNames[i] = fmt.Sprintf("%s:%d", s.Name, s.Port)
+			if s.Host {
+				svcNames[i] += " (host)"
+			}
+		}
+
+		var profiles []string
+		if _, ok := running[p.Name]; ok && !p.HostMode {
+			profiles, _ = compose.ActiveProfiles(ctx, p.Name, p.Dir, p.ComposeFiles())
 		}
 
 		output.Projects = append(output.Projects, projectStatus{
@@ -92,6 +123,32 @@ func runStatus(cmd *cobra.Command, args []string) error {
 			URLs:     urls,
 			Services: svcNames,
 			Flavors:  p.Flavors,
+			Tags:     p.Tags,
+			Profiles: profiles,
+		})
+	}
+
+	var groupOrder []string
+	if flagStatusByTag {
+		output.Groups = make(map[string][]string)
+		for _, p := range output.Projects {
+			tags := p.Tags
+			if len(tags) == 0 {
+				tags = []string{untaggedGroup}
+			}
+			for _, t := range tags {
+				if _, ok := output.Groups[t]; !ok {
+					groupOrder = append(groupOrder, t)
+				}
+				output.Groups[t] = append(output.Groups[t], p.Name)
+			}
+		}
+		sort.Slice(groupOrder, func(i, j int) bool {
+			// Untagged projects go last
+			if (groupOrder[i] == untaggedGroup) != (groupOrder[j] == untaggedGroup) {
+				return groupOrder[j] == untaggedGroup
+			}
+			return groupOrder[i] < groupOrder[j]
 		})
 	}
 
@@ -99,14 +156,49 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return ui.PrintJSON(output)
 	}
 
-	// Table output
-	headers := []string{"NAME", "MODE", "STATUS", "URLS"}
-	var rows [][]string
+	// Table output; the profiles column only appears when some project has
+	// profiles active
+	withProfiles := false
 	for _, p := range output.Projects {
-		rows = append(rows, []string{p.Name, p.Mode, p.Status, strings.Join(p.URLs, ", ")})
+		withProfiles = withProfiles || len(p.Profiles) > 0
+	}
+	headers := []string{"NAME", "MODE", "STATUS", "URLS"}
+	if withProfiles {
+		headers = []string{"NAME", "MODE", "STATUS", "PROFILES", "URLS"}
+	}
+	if !flagStatusByTag {
+		fmt.Println()
+		ui.PrintTable(headers, statusRows(output.Projects, nil, withProfiles))
+		fmt.Println()
+		return nil
+	}
+
+	for _, group := range groupOrder {
+		members := make(map[string]bool)
+		for _, name := range output.Groups[group] {
+			members[name] = true
+		}
+		fmt.Println()
+		fmt.Printf("%s:\n", group)
+		ui.PrintTable(headers, statusRows(output.Projects, members, withProfiles))
 	}
-	fmt.Println()
-	ui.PrintTable(headers, rows)
 	fmt.Println()
 	return nil
 }
+
+// statusRows builds table rows for the given projects, limited to members
+// when it is non-nil.
+func statusRows(projects []projectStatus, members map[string]bool, withProfiles bool) [][]string {
+	var rows [][]string
+	for _, p := range projects {
+		if members != nil && !members[p.Name] {
+			continue
+		}
+		if withProfiles {
+			rows = append(rows, []string{p.Name, p.Mode, p.Status, joinOrDash(p.Profiles), strings.Join(p.URLs, ", ")})
+		} else {
+			rows = append(rows, []string{p.Name, p.Mode, p.Status, strings.Join(p.URLs, ", ")})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/supervisor"
+	"github.com/spf13/cobra"
+)
+
+// superviseCmd is the background process 'di up' starts for a project's host
+// services and 'di down' stops.
+var superviseCmd = &cobra.Command{
+	Use:    "supervise <project>",
+	Short:  "Run and restart a project's host service commands",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runSupervise,
+}
+
+func init() {
+	rootCmd.AddCommand(superviseCmd)
+}
+
+func runSupervise(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	pidFile, err := supervisor.Claim(name)
+	if err != nil {
+		return err
+	}
+	defer pidFile.Release()
+
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+	p := reg.Get(name)
+	if p == nil {
+		return fmt.Errorf("project %q not found in registry", name)
+	}
+	procs, err := supervisor.Processes(*p)
+	if err != nil {
+		return err
+	}
+	if len(procs) == 0 {
+		return fmt.Errorf("%s has no host service commands to run", name)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	return supervisor.Run(ctx, p.Name, procs)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/project"
+	"github.com/heysarver/devinfra/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var flagSyncDryRun bool
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [project]",
+	Short: "Reconcile a project with its .devinfra.yaml manifest",
+	Long: `Update a registered project from the .devinfra.yaml manifest committed in its
+directory. Services, flavors, compose files, and mode are taken from the
+manifest; missing flavor overlays are rendered and routing config is
+regenerated.
+
+Example manifest:
+  name: myapp
+  compose_files:
+    - docker-compose.yaml
+    - docker-compose.override.yaml
+  services:
+    - name: web
+      port: 3000
+  flavors:
+    - postgres`,
+	GroupID:           "project",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: projectNameCompletion,
+	RunE:              runSync,
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&flagSyncDryRun, "dry-run", false, "show what would change without applying it")
+	rootCmd.AddCommand(syncCmd)
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		name, err := pickProject("Select project to sync")
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			ui.Info("Cancelled.")
+			return nil
+		}
+		args = []string{name}
+	}
+
+	project.TemplatesFS = embeddedTemplatesFS
+	return project.Sync(cmd.Context(), config.Current(), args[0], flagSyncDryRun)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/project"
+	"github.com/heysarver/devinfra/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:     "tag",
+	Short:   "Manage project tags",
+	Long:    "Group projects with tags so they can be started, stopped, and inspected together with --tag or --selector.",
+	GroupID: "project",
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:               "add <project> <tag>...",
+	Short:             "Add tags to a project",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: tagArgsCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return project.AddTags(args[0], args[1:])
+	},
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:               "remove <project> <tag>...",
+	Short:             "Remove tags from a project",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: tagArgsCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return project.RemoveTags(args[0], args[1:])
+	},
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tags and the projects carrying them",
+	Args:  cobra.NoArgs,
+	RunE:  runTagList,
+}
+
+func init() {
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRemoveCmd)
+	tagCmd.AddCommand(tagListCmd)
+	rootCmd.AddCommand(tagCmd)
+}
+
+func runTagList(cmd *cobra.Command, args []string) error {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string][]string)
+	for _, tag := range reg.Tags() {
+		projects, _ := reg.Filter(config.ProjectFilter{Tags: []string{tag}})
+		for _, p := range projects {
+			groups[tag] = append(groups[tag], p.Name)
+		}
+	}
+
+	if flagJSON {
+		return ui.PrintJSON(groups)
+	}
+
+	if len(groups) == 0 {
+		ui.Info("No tags yet. Add one with: di tag add <project> <tag>")
+		return nil
+	}
+
+	headers := []string{"TAG", "PROJECTS"}
+	var rows [][]string
+	for _, tag := range reg.Tags() {
+		rows = append(rows, []string{tag, strings.Join(groups[tag], ", ")})
+	}
+	fmt.Println()
+	ui.PrintTable(headers, rows)
+	fmt.Println()
+	return nil
+}
+
+// tagArgsCompletion completes the project name first, then existing tags.
+func tagArgsCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return projectNameCompletion(cmd, args, toComplete)
+	}
+	return tagCompletion(cmd, args, toComplete)
+}
+
+func tagCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return reg.Tags(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -1,21 +1,36 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/heysarver/devinfra/internal/compose"
 	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/project"
+	"github.com/heysarver/devinfra/internal/supervisor"
 	"github.com/heysarver/devinfra/internal/ui"
 	"github.com/spf13/cobra"
 )
 
-var flagAll bool
+var (
+	flagAll          bool
+	flagUpDepTimeout time.Duration
+	flagUpProfiles   []string
+	flagParallel     int
+	flagUpWait       bool
+	flagUpWaitTime   time.Duration
+)
 
 var upCmd = &cobra.Command{
 	Use:   "up [project]",
 	Short: "Start infrastructure or a project",
-	Long:  "Start core infrastructure (Traefik, DNSMasq, socket-proxy) or a specific project's containers.",
+	Long:  "Start core infrastructure (Traefik, DNSMasq, socket-proxy) or a specific project's containers. Projects listed in a project's depends_on are started first and waited on until ready.\n\nHost services with a command, in the registry or the project's Procfile, are run on the host by a background supervisor that restarts them when they crash; 'di down' stops them and 'di logs' shows their output.\n\nWith --all or selectors, projects start concurrently (see --parallel) while still respecting depends_on, and a summary is printed at the end. The command fails if any project did not start.\n\nWith --wait, di up returns only once containers with health checks are healthy and every service answers over HTTPS through Traefik, verified against the mkcert CA. Routes that Traefik answers with 404 (no matching router) or 502 (backend not listening) fail with a diagnosis.\n\nServices in compose profiles start only when the profile is enabled: by the project's default profiles (see 'di profile'), or by --profile, which replaces the defaults for this run.",
 	GroupID: "infra",
 	Args:  cobra.MaximumNArgs(1),
 	ValidArgsFunction: projectNameCompletion,
@@ -24,50 +39,61 @@ var upCmd = &cobra.Command{
 
 func init() {
 	upCmd.Flags().BoolVar(&flagAll, "all", false, "start all registered projects")
+	upCmd.Flags().DurationVar(&flagUpDepTimeout, "dep-timeout", 2*time.Minute, "how long to wait for each dependency project to become ready")
+	upCmd.Flags().StringSliceVar(&flagUpProfiles, "profile", nil, "compose profile to enable instead of the project's defaults (repeatable)")
+	upCmd.Flags().BoolVar(&flagUpWait, "wait", false, "wait until containers are healthy and every service answers over HTTPS through Traefik")
+	upCmd.Flags().DurationVar(&flagUpWaitTime, "wait-timeout", 2*time.Minute, "how long --wait waits for each project")
+	upCmd.Flags().IntVar(&flagParallel, "parallel", project.DefaultParallel, "how many projects to start at once with --all or selectors")
+	addSelectorFlags(upCmd)
 	rootCmd.AddCommand(upCmd)
 }
 
 func runUp(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
-	// If --all, start infra then all projects
-	if flagAll {
-		if !compose.IsInfraRunning(ctx) {
-			ui.Info("Starting core infrastructure...")
-			if err := compose.Up(ctx); err != nil {
-				return fmt.Errorf("starting infrastructure: %w", err)
-			}
-			ui.Ok("Core infrastructure started.")
+	// If --all or a tag selection, start infra then those projects
+	if flagAll || !projectFilter().IsZero() {
+		if cmd.Flags().Changed("profile") {
+			return fmt.Errorf("--profile only applies when starting a single project; set defaults with 'di profile add'")
+		}
+		projects, err := groupProjects(args)
+		if err != nil {
+			return err
 		}
-
 		reg, err := config.LoadRegistry()
 		if err != nil {
 			return err
 		}
+		requested := make(map[string]bool, len(projects))
+		names := make([]string, len(projects))
+		for i, p := range projects {
+			requested[p.Name] = true
+			names[i] = p.Name
+		}
+		order, err := reg.StartOrder(names)
+		if err != nil {
+			return err
+		}
 
-		for _, p := range reg.Projects {
-			if p.HostMode {
-				ui.Warn("Skipping host-mode project %s", p.Name)
-				continue
-			}
-			ui.Info("Starting %s...", p.Name)
-			files := p.ComposeFiles()
-			if err := compose.ProjectUp(ctx, p.Name, p.Dir, files); err != nil {
-				ui.Warn("Failed to start %s: %v", p.Name, err)
-			} else {
-				ui.Ok("Started %s", p.Name)
+		if !compose.IsInfraRunning(ctx) {
+			if err := startInfra(ctx); err != nil {
+				return err
 			}
 		}
+
+		results := startProjects(ctx, order, requested, true)
+		project.PrintSummary(results)
+		if failed := project.Failed(results); len(failed) > 0 {
+			return fmt.Errorf("%d of %d projects did not start: %s", len(failed), len(results), strings.Join(failed, ", "))
+		}
 		return nil
 	}
 
 	// If no project specified, start infra only
 	if len(args) == 0 {
-		ui.Info("Starting core infrastructure...")
-		if err := compose.Up(ctx); err != nil {
-			return fmt.Errorf("starting infrastructure: %w", err)
+		if err := startInfra(ctx); err != nil {
+			return err
 		}
-		ui.Ok("Core infrastructure started.")
 		return nil
 	}
 
@@ -81,41 +107,318 @@ func runUp(cmd *cobra.Command, args []string) error {
 	if p == nil {
 		return fmt.Errorf("project %q not found in registry", name)
 	}
+	order, err := reg.StartOrder([]string{name})
+	if err != nil {
+		return err
+	}
+
+	// --profile replaces the project's default profiles for this run
+	run := *p
+	if cmd.Flags().Changed("profile") {
+		for _, pr := range flagUpProfiles {
+			if err := config.ValidateProfile(pr); err != nil {
+				return err
+			}
+		}
+		run.Profiles = flagUpProfiles
+		project.WarnUnknownProfiles(run, run.Profiles)
+		for i := range order {
+			if order[i].Name == name {
+				order[i] = run
+			}
+		}
+	}
 
 	// Check if infra is running first
 	if !compose.IsInfraRunning(ctx) {
 		if flagYes {
-			ui.Info("Starting core infrastructure...")
-			if err := compose.Up(ctx); err != nil {
-				return fmt.Errorf("starting infrastructure: %w", err)
+			if err := startInfra(ctx); err != nil {
+				return err
 			}
-			ui.Ok("Core infrastructure started.")
 		} else {
 			fmt.Fprintln(os.Stderr, "Core infrastructure is not running.")
 			fmt.Fprint(os.Stderr, "Start it now? [Y/n] ")
 			var answer string
 			_, _ = fmt.Scanln(&answer)
 			if answer == "" || answer == "y" || answer == "Y" || answer == "yes" {
-				ui.Info("Starting core infrastructure...")
-				if err := compose.Up(ctx); err != nil {
-					return fmt.Errorf("starting infrastructure: %w", err)
+				if err := startInfra(ctx); err != nil {
+					return err
 				}
-				ui.Ok("Core infrastructure started.")
 			} else {
 				return fmt.Errorf("core infrastructure must be running first; run 'di up'")
 			}
 		}
 	}
 
-	ui.Info("Starting %s...", name)
-	files := p.ComposeFiles()
-	if err := compose.ProjectUp(ctx, p.Name, p.Dir, files); err != nil {
-		return fmt.Errorf("starting %s: %w", name, err)
+	if len(order) == 1 {
+		ui.Info("Starting %s...", name)
+		if err := project.RefreshOverlay(config.Current(), run); err != nil {
+			return err
+		}
+		if hasContainers(run) {
+			if err := checkProjectPorts(ctx, os.Stderr, run); err != nil {
+				return err
+			}
+			if err := compose.ProjectUp(ctx, run.Name, run.Dir, run.ComposeFiles(), run.Profiles); err != nil {
+				return fmt.Errorf("starting %s: %w", name, err)
+			}
+		}
+		if err := startHostProcesses(os.Stderr, run); err != nil {
+			return err
+		}
+		ui.Ok("Started %s", name)
+		if flagUpWait {
+			if err := waitForProject(ctx, os.Stderr, run); err != nil {
+				return err
+			}
+		}
+	} else if failed := project.Failed(startProjects(ctx, order, map[string]bool{name: true}, false)); len(failed) > 0 {
+		return fmt.Errorf("could not start %s: %s failed", name, strings.Join(failed, ", "))
+	}
+	reportHostServices(os.Stderr, p)
+	return nil
+}
+
+// startInfra starts the core infrastructure after checking that its host
+// ports are free.
+func startInfra(ctx context.Context) error {
+	ports, err := compose.InfraPorts()
+	if err != nil {
+		ui.Warn("Could not read infrastructure ports: %v", err)
+	}
+	if err := checkPorts(ctx, infraProject, "core infrastructure", ports); err != nil {
+		return err
+	}
+	ui.Info("Starting core infrastructure...")
+	if err := compose.Up(ctx); err != nil {
+		return fmt.Errorf("starting infrastructure: %w", err)
+	}
+	ui.Ok("Core infrastructure started.")
+	return nil
+}
+
+// startProjects starts projects concurrently, at most --parallel at a time.
+// A project starts only once the dependencies it has in order (see
+// Registry.StartOrder) are ready; projects whose dependency failed are
+// skipped. Dependencies pulled in by the graph that are already running are
+// left alone, while requested projects are always brought up.
+func startProjects(ctx context.Context, order []config.Project, requested map[string]bool, skipHostMode bool) []project.Result {
+	running, err := compose.RunningContainers(ctx)
+	if err != nil {
+		ui.Warn("Could not determine running containers: %v", err)
+	}
+
+	// Projects others in the run depend on are waited on until ready
+	needed := make(map[string]bool)
+	for _, p := range order {
+		for _, dep := range p.DependsOn {
+			needed[dep] = true
+		}
+	}
+
+	var tasks []project.Task
+	for _, p := range order {
+		if p.HostMode && !supervised(p) && skipHostMode && requested[p.Name] && !needed[p.Name] {
+			ui.Warn("Skipping host-mode project %s", p.Name)
+			continue
+		}
+		tasks = append(tasks, project.Task{
+			Name:  p.Name,
+			After: p.DependsOn,
+			Run: func(ctx context.Context, out io.Writer) error {
+				return startProject(ctx, out, p, requested[p.Name], len(running[p.Name]) > 0, needed[p.Name], skipHostMode)
+			},
+		})
+	}
+	return project.Executor{Parallel: flagParallel}.Run(ctx, tasks)
+}
+
+// startProject is one startProjects task. When needed, it waits for the
+// project to be ready so its dependents can start. Host-mode dependencies
+// without host service commands are not managed by di, so they are only
+// checked for listening ports.
+func startProject(ctx context.Context, out io.Writer, p config.Project, requested, running, needed, skipHostMode bool) error {
+	if p.HostMode && !supervised(p) && (skipHostMode || !requested) {
+		if requested {
+			ui.WarnTo(out, "Skipping host-mode project %s", p.Name)
+		}
+		if needed {
+			reportHostServices(out, &p)
+		}
+		return nil
+	}
+
+	if _, ok := supervisor.Running(p.Name); ok && p.HostMode {
+		running = true
+	}
+	if !requested && running {
+		ui.InfoTo(out, "Dependency %s is already running", p.Name)
+	} else {
+		ui.InfoTo(out, "Starting %s...", p.Name)
+		if err := project.RefreshOverlay(config.Current(), p); err != nil {
+			return err
+		}
+		if hasContainers(p) {
+			if err := checkProjectPorts(ctx, out, p); err != nil {
+				return err
+			}
+			if err := compose.ProjectUp(ctx, p.Name, p.Dir, p.ComposeFiles(), p.Profiles); err != nil {
+				return err
+			}
+		}
+		if err := startHostProcesses(out, p); err != nil {
+			return err
+		}
+		ui.OkTo(out, "Started %s", p.Name)
+	}
+
+	if flagUpWait && requested {
+		return waitForProject(ctx, out, p)
+	}
+	if needed {
+		ui.InfoTo(out, "Waiting for %s to be ready...", p.Name)
+		if hasContainers(p) {
+			if err := compose.WaitReady(ctx, p.Name, flagUpDepTimeout); err != nil {
+				return err
+			}
+		}
+		if err := waitListening(ctx, p, flagUpDepTimeout); err != nil {
+			return err
+		}
+		ui.OkTo(out, "%s is ready", p.Name)
 	}
-	ui.Ok("Started %s", name)
 	return nil
 }
 
+// hasContainers reports whether p has anything for compose to run: its own
+// services, or flavor containers next to host-mode services.
+func hasContainers(p config.Project) bool {
+	return !p.HostMode || len(p.Flavors) > 0
+}
+
+// supervised reports whether di runs commands for any of p's host services.
+// An unreadable Procfile counts, so starting the project reports the error.
+func supervised(p config.Project) bool {
+	procs, err := supervisor.Processes(p)
+	return err != nil || len(procs) > 0
+}
+
+// startHostProcesses starts the supervisor running p's host service commands
+// in the background, unless it is already running.
+func startHostProcesses(out io.Writer, p config.Project) error {
+	procs, err := supervisor.Processes(p)
+	if err != nil {
+		return fmt.Errorf("reading host service commands of %s: %w", p.Name, err)
+	}
+	if len(procs) == 0 {
+		return nil
+	}
+	if pid, ok := supervisor.Running(p.Name); ok {
+		ui.InfoTo(out, "Host processes of %s are already running (pid %d); 'di down %s' stops them", p.Name, pid, p.Name)
+		return nil
+	}
+	for _, proc := range procs {
+		if proc.Port != 0 && hostPortListening(proc.Port) {
+			ui.WarnTo(out, "Port %d of host service %s is already in use; its command may fail to start", proc.Port, proc.Name)
+		}
+	}
+	if err := supervisor.Start(p.Name); err != nil {
+		return fmt.Errorf("starting host processes of %s: %w", p.Name, err)
+	}
+	for _, proc := range procs {
+		ui.OkTo(out, "Started host process %s: %s", proc.Name, proc.Command)
+	}
+	return nil
+}
+
+// waitListening waits until each of p's supervised host services accepts
+// connections on its port.
+func waitListening(ctx context.Context, p config.Project, timeout time.Duration) error {
+	procs, err := supervisor.Processes(p)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for _, proc := range procs {
+		for proc.Port != 0 && !hostPortListening(proc.Port) {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%s not ready within %s: %s is not listening on :%d ('di logs %s' shows its output)", p.Name, timeout, proc.Name, proc.Port, p.Name)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}
+	return nil
+}
+
+// waitForProject waits up to --wait-timeout for p's containers to be ready
+// and for each of its services to answer through Traefik, reporting each
+// service's readiness.
+func waitForProject(ctx context.Context, out io.Writer, p config.Project) error {
+	ctx, cancel := context.WithTimeout(ctx, flagUpWaitTime)
+	defer cancel()
+
+	if hasContainers(p) {
+		ui.InfoTo(out, "Waiting for %s containers to be ready...", p.Name)
+		if err := compose.WaitReady(ctx, p.Name, flagUpWaitTime); err != nil {
+			return err
+		}
+	}
+
+	routes := project.Routes(p)
+	if len(routes) == 0 {
+		return nil
+	}
+	ui.InfoTo(out, "Waiting for %s routes...", p.Name)
+	checks, err := compose.WaitRoutes(ctx, config.Current(), routes)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, c := range checks {
+		if c.Err != nil {
+			ui.WarnTo(out, "%s not ready at %s: %v", c.Service, c.URL, c.Err)
+			failed = append(failed, c.Service)
+			continue
+		}
+		ui.OkTo(out, "%s ready at %s (%d)", c.Service, c.URL, c.Status)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s not ready within %s: %s", p.Name, flagUpWaitTime, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// reportHostServices lists the host services of a project that have no
+// command for di to run. Any that aren't listening yet are flagged.
+func reportHostServices(out io.Writer, p *config.Project) {
+	procs, _ := supervisor.Processes(*p)
+	for _, svc := range p.HostServices() {
+		if slices.ContainsFunc(procs, func(proc supervisor.Process) bool { return proc.Name == svc.Name }) {
+			continue
+		}
+		if hostPortListening(svc.Port) {
+			ui.OkTo(out, "Host service %s is listening on :%d", svc.Name, svc.Port)
+		} else {
+			ui.WarnTo(out, "Host service %s is not listening on :%d yet; start it on the host, or give it a command to have di run it", svc.Name, svc.Port)
+		}
+	}
+}
+
+// hostPortListening reports whether something accepts TCP connections on
+// localhost at port.
+func hostPortListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 300*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
 
 func projectNameCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if len(args) > 0 {
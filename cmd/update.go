@@ -61,11 +61,15 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		}
 
 		files := p.ComposeFiles()
+		profiles := p.Profiles
+		if active, err := compose.ActiveProfiles(ctx, p.Name, p.Dir, files); err == nil && len(active) > 0 {
+			profiles = active
+		}
 		ui.Info("Restarting %s...", name)
 		if err := compose.ProjectDown(ctx, p.Name, p.Dir, files); err != nil {
 			ui.Warn("Failed to stop %s: %v", name, err)
 		}
-		if err := compose.ProjectUp(ctx, p.Name, p.Dir, files); err != nil {
+		if err := compose.ProjectUp(ctx, p.Name, p.Dir, files, profiles); err != nil {
 			return fmt.Errorf("starting %s: %w", name, err)
 		}
 		ui.Ok("Restarted %s", name)
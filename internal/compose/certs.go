@@ -6,48 +6,50 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/heysarver/devinfra/internal/config"
 	"github.com/heysarver/devinfra/internal/ui"
 )
 
-// GenerateCerts generates TLS certificates for a project using mkcert.
-func GenerateCerts(ctx context.Context, name string) error {
-	tld := config.TLD()
-	certsDir := config.CertsDir()
-	if err := os.MkdirAll(certsDir, 0755); err != nil {
+// GenerateCerts generates TLS certificates for a project using mkcert: one for
+// the project's local domain and one for each of its extra domains.
+func GenerateCerts(ctx context.Context, s *config.Settings, name string, extraDomains []string) error {
+	tld := s.TLD
+	if err := os.MkdirAll(config.CertsDir(), 0755); err != nil {
 		return fmt.Errorf("creating certs dir: %w", err)
 	}
 
 	ui.Info("Generating certs for %s.%s...", name, tld)
-	cmd := exec.CommandContext(ctx, "mkcert", fmt.Sprintf("%s.%s", name, tld), fmt.Sprintf("*.%s.%s", name, tld))
-	cmd.Dir = certsDir
-	cmd.Stdout = os.Stderr
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("mkcert: %w", err)
+	if err := mkcertWildcard(ctx, fmt.Sprintf("%s.%s", name, tld)); err != nil {
+		return err
 	}
-
-	// Set restrictive permissions on key file
-	keyFile := filepath.Join(certsDir, fmt.Sprintf("%s.%s+1-key.pem", name, tld))
-	if err := os.Chmod(keyFile, 0600); err != nil {
-		ui.Warn("Could not set key file permissions: %v", err)
+	for _, base := range extraBaseDomains(extraDomains) {
+		ui.Info("Generating certs for %s...", base)
+		if err := mkcertWildcard(ctx, base); err != nil {
+			return err
+		}
 	}
 
 	// Create Traefik TLS config
-	return WriteTLSConfig(name)
+	return WriteTLSConfig(s, name, extraDomains)
 }
 
 // GenerateInfraCerts generates TLS certificates for the Traefik dashboard.
-func GenerateInfraCerts(ctx context.Context) error {
-	tld := config.TLD()
-	certsDir := config.CertsDir()
-	if err := os.MkdirAll(certsDir, 0755); err != nil {
+func GenerateInfraCerts(ctx context.Context, s *config.Settings) error {
+	if err := os.MkdirAll(config.CertsDir(), 0755); err != nil {
 		return fmt.Errorf("creating certs dir: %w", err)
 	}
 
 	ui.Info("Generating infrastructure certs...")
-	cmd := exec.CommandContext(ctx, "mkcert", fmt.Sprintf("traefik.%s", tld), fmt.Sprintf("*.traefik.%s", tld))
+	return mkcertWildcard(ctx, fmt.Sprintf("traefik.%s", s.TLD))
+}
+
+// mkcertWildcard issues a certificate for base and *.base into the certs dir.
+// mkcert names the files <base>+1.pem and <base>+1-key.pem.
+func mkcertWildcard(ctx context.Context, base string) error {
+	certsDir := config.CertsDir()
+	cmd := exec.CommandContext(ctx, "mkcert", base, "*."+base)
 	cmd.Dir = certsDir
 	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
@@ -56,27 +58,37 @@ func GenerateInfraCerts(ctx context.Context) error {
 	}
 
 	// Set restrictive permissions on key file
-	keyFile := filepath.Join(certsDir, fmt.Sprintf("traefik.%s+1-key.pem", tld))
+	keyFile := filepath.Join(certsDir, fmt.Sprintf("%s+1-key.pem", base))
 	if err := os.Chmod(keyFile, 0600); err != nil {
 		ui.Warn("Could not set key file permissions: %v", err)
 	}
-
 	return nil
 }
 
-// WriteTLSConfig writes a Traefik TLS dynamic config for a project.
-func WriteTLSConfig(name string) error {
-	tld := config.TLD()
+// extraBaseDomains strips the "*." prefix from a project's extra domains.
+func extraBaseDomains(extraDomains []string) []string {
+	bases := make([]string, len(extraDomains))
+	for i, d := range extraDomains {
+		bases[i] = strings.TrimPrefix(d, "*.")
+	}
+	return bases
+}
+
+// WriteTLSConfig writes a Traefik TLS dynamic config for a project, listing the
+// local domain's cert followed by one per extra domain.
+func WriteTLSConfig(s *config.Settings, name string, extraDomains []string) error {
+	tld := s.TLD
 	dynamicDir := config.DynamicDir()
 	if err := os.MkdirAll(dynamicDir, 0755); err != nil {
 		return fmt.Errorf("creating dynamic dir: %w", err)
 	}
 
-	content := fmt.Sprintf(`tls:
-  certificates:
-    - certFile: /certs/%s.%s+1.pem
-      keyFile: /certs/%s.%s+1-key.pem
-`, name, tld, name, tld)
+	var b strings.Builder
+	b.WriteString("tls:\n  certificates:\n")
+	for _, base := range append([]string{fmt.Sprintf("%s.%s", name, tld)}, extraBaseDomains(extraDomains)...) {
+		fmt.Fprintf(&b, "    - certFile: /certs/%s+1.pem\n      keyFile: /certs/%s+1-key.pem\n", base, base)
+	}
+	content := b.String()
 
 	path := filepath.Join(dynamicDir, fmt.Sprintf("tls-%s.yaml", name))
 	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
@@ -87,9 +99,10 @@ func WriteTLSConfig(name string) error {
 	return nil
 }
 
-// RemoveCerts removes certificates and TLS config for a project.
-func RemoveCerts(name string) error {
-	tld := config.TLD()
+// RemoveCerts removes certificates and TLS config for a project, including
+// the certs issued for its extra domains.
+func RemoveCerts(s *config.Settings, name string, extraDomains []string) error {
+	tld := s.TLD
 	certsDir := config.CertsDir()
 	dynamicDir := config.DynamicDir()
 
@@ -99,6 +112,9 @@ func RemoveCerts(name string) error {
 		// Also catch certs from a previous TLD if the user changed it
 		filepath.Join(certsDir, fmt.Sprintf("%s.*+*.pem", name)),
 	}
+	for _, base := range extraBaseDomains(extraDomains) {
+		patterns = append(patterns, filepath.Join(certsDir, fmt.Sprintf("%s+*.pem", base)))
+	}
 	for _, pattern := range patterns {
 		matches, _ := filepath.Glob(pattern)
 		for _, m := range matches {
@@ -109,8 +125,5 @@ func RemoveCerts(name string) error {
 	// Remove TLS config
 	_ = os.Remove(filepath.Join(dynamicDir, fmt.Sprintf("tls-%s.yaml", name)))
 
-	// Remove host config if present
-	_ = os.Remove(filepath.Join(dynamicDir, fmt.Sprintf("host-%s.yaml", name)))
-
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -13,6 +14,7 @@ import (
 	"text/template"
 
 	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/docker"
 	"github.com/heysarver/devinfra/internal/ui"
 )
 
@@ -30,6 +32,11 @@ type embedData struct {
 	TLD           string
 	RemoteEnabled bool
 	ACMEEmail     string
+	DNSUpstream   []string
+	DNSForward    []config.DNSForward
+	RuntimeSocket string
+	HostGateway   bool // map host.docker.internal to the host for Traefik
+	TCP           []TCPEntrypoint
 }
 
 // renderTemplate renders src as a Go template with the given data and returns the result.
@@ -46,13 +53,20 @@ func renderTemplate(name string, src []byte, data embedData) ([]byte, error) {
 }
 
 // ExtractEmbedded writes embedded compose files to the config directory,
-// rendering each file with the given TLD and current remote config.
-func ExtractEmbedded(tld string) error {
-	remote := config.Remote()
+// rendering each file with the given settings' TLD and remote config.
+func ExtractEmbedded(s *config.Settings) error {
 	data := embedData{
-		TLD:           tld,
-		RemoteEnabled: remote.Enabled,
-		ACMEEmail:     remote.ACMEEmail,
+		TLD:           s.TLD,
+		RemoteEnabled: s.Remote.Enabled,
+		ACMEEmail:     s.Remote.ACMEEmail,
+		DNSUpstream:   s.DNSUpstream,
+		DNSForward:    s.DNSForward,
+		RuntimeSocket: runtimeSocket(s),
+		// Podman already maps host.docker.internal to the host
+		HostGateway: RuntimeFor(s).Name() != "podman",
+	}
+	if s.TCPRouting {
+		data.TCP = TCPEntrypoints
 	}
 
 	entries := []struct {
@@ -85,17 +99,49 @@ func ExtractEmbedded(tld string) error {
 	return nil
 }
 
+// runtimeSocket returns the API socket mounted into socket-proxy. Runtimes
+// without one fall back to the standard docker socket path so the compose file
+// stays valid; 'di doctor' reports that routes won't be discovered.
+func runtimeSocket(s *config.Settings) string {
+	if socket := RuntimeFor(s).Socket(); socket != "" {
+		return socket
+	}
+	return docker.DefaultSocket
+}
+
 // ExtractSetupScript extracts and returns the path to a platform setup script,
-// rendering it with the given TLD substituted for {{.TLD}} placeholders.
-func ExtractSetupScript(platform, tld string) (string, error) {
-	name := fmt.Sprintf("setup-%s.sh", strings.ToLower(platform))
+// rendered with the settings' TLD and, for the host dnsmasq on Linux, its DNS
+// servers.
+func ExtractSetupScript(platform string, s *config.Settings) (string, error) {
+	return extractScript(fmt.Sprintf("setup-%s.sh", strings.ToLower(platform)), platform, s)
+}
+
+// ExtractResolverScript extracts and returns the path to the platform script
+// that points the host resolver at a new DNS port. The script reads DNS_PORT
+// and OLD_DNS_PORT from its environment.
+func ExtractResolverScript(platform string, s *config.Settings) (string, error) {
+	return extractScript(fmt.Sprintf("resolver-%s.sh", strings.ToLower(platform)), platform, s)
+}
+
+// ExtractDNSServersScript extracts and returns the path to the platform script
+// that rewrites the host dnsmasq config left by setup with the settings'
+// upstream servers and forwarding rules. Only Linux has one.
+func ExtractDNSServersScript(platform string, s *config.Settings) (string, error) {
+	return extractScript(fmt.Sprintf("dns-servers-%s.sh", strings.ToLower(platform)), platform, s)
+}
+
+func extractScript(name, platform string, s *config.Settings) (string, error) {
 	embedPath := filepath.Join("embed", "scripts", name)
 	data, err := fs.ReadFile(embeddedScripts, embedPath)
 	if err != nil {
 		return "", fmt.Errorf("no setup script for platform %s: %w", platform, err)
 	}
 
-	rendered, err := renderTemplate(name, data, embedData{TLD: tld})
+	rendered, err := renderTemplate(name, data, embedData{
+		TLD:         s.TLD,
+		DNSUpstream: s.DNSUpstream,
+		DNSForward:  s.DNSForward,
+	})
 	if err != nil {
 		return "", err
 	}
@@ -138,72 +184,91 @@ func Logs(ctx context.Context) error {
 	return runAttached(ctx, config.ComposeDir(), "logs", "-f")
 }
 
+// RestartDNS recreates the dnsmasq container so it picks up a new DNS_PORT
+// mapping or dnsmasq.conf.
+func RestartDNS(ctx context.Context) error {
+	return run(ctx, config.ComposeDir(), "up", "-d", "--force-recreate", "dnsmasq")
+}
+
 // IsInfraRunning checks if the core infrastructure containers are running.
 func IsInfraRunning(ctx context.Context) bool {
-	cmd := exec.CommandContext(ctx, "docker", "inspect", "-f", "{{.State.Running}}", "traefik")
-	out, err := cmd.Output()
-	if err != nil {
-		return false
-	}
-	return strings.TrimSpace(string(out)) == "true"
+	return CurrentRuntime().ContainerRunning(ctx, "traefik")
 }
 
-// ProjectUp starts a specific project's containers.
-func ProjectUp(ctx context.Context, name, dir string, composeFiles []string) error {
+// ProjectUp starts a specific project's containers with the given compose
+// profiles enabled.
+func ProjectUp(ctx context.Context, name, dir string, composeFiles, profiles []string) error {
 	args := buildComposeArgs(name, composeFiles)
+	args = append(args, profileArgs(profiles)...)
 	args = append(args, "up", "-d")
 	return runRaw(ctx, dir, args...)
 }
 
-// ProjectDown stops a specific project's containers.
+// ProjectDown stops a specific project's containers, whichever profiles they
+// were started with.
 func ProjectDown(ctx context.Context, name, dir string, composeFiles []string) error {
 	args := buildComposeArgs(name, composeFiles)
+	args = append(args, allProfileArgs(dir, composeFiles)...)
 	args = append(args, "down")
 	return runRaw(ctx, dir, args...)
 }
 
+// LogOptions selects which project logs to show.
+type LogOptions struct {
+	Services []string // all services if empty
+	Since    string   // a duration ago, e.g. "10m", or a timestamp
+	Tail     string   // lines to show from the end of each container's log
+	NoFollow bool     // stop at the end of the log instead of following it
+}
+
+func (o LogOptions) args() []string {
+	args := []string{"logs"}
+	if !o.NoFollow {
+		args = append(args, "-f")
+	}
+	if o.Since != "" {
+		args = append(args, "--since", o.Since)
+	}
+	if o.Tail != "" {
+		args = append(args, "--tail", o.Tail)
+	}
+	return args
+}
+
 // ProjectLogs tails logs from a specific project.
-func ProjectLogs(ctx context.Context, name, dir string, composeFiles []string) error {
+func ProjectLogs(ctx context.Context, name, dir string, composeFiles []string, opts LogOptions) error {
 	args := buildComposeArgs(name, composeFiles)
-	args = append(args, "logs", "-f")
+	args = append(args, allProfileArgs(dir, composeFiles)...)
+	args = append(args, opts.args()...)
+	args = append(args, opts.Services...)
 	return runRawAttached(ctx, dir, args...)
 }
 
+// ServiceLogsTo tails one service's logs into the given writers instead of
+// the terminal, so several can be followed at once. Lines carry no compose
+// prefix or colors and start with an RFC 3339 timestamp.
+func ServiceLogsTo(ctx context.Context, name, dir string, composeFiles []string, service string, opts LogOptions, stdout, stderr io.Writer) error {
+	args := buildComposeArgs(name, composeFiles)
+	args = append(args, allProfileArgs(dir, composeFiles)...)
+	args = append(args, opts.args()...)
+	args = append(args, "--no-log-prefix", "--no-color", "--timestamps", service)
+	cmd := exec.CommandContext(ctx, CurrentRuntime().Binary(), args...)
+	cmd.Dir = dir
+	cmd.Env = config.Current().ComposeEnv()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
 // RunningContainers returns a map of compose project name -> list of running container names.
-// Uses a single docker ps call for efficiency.
+// Uses a single container list query for efficiency.
 func RunningContainers(ctx context.Context) (map[string][]string, error) {
-	cmd := exec.CommandContext(ctx, "docker", "ps", "--format", "{{.Labels}}\t{{.Names}}", "--filter", "status=running")
-	out, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("docker ps: %w", err)
-	}
-
-	result := make(map[string][]string)
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		if line == "" {
-			continue
-		}
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		labels := parts[0]
-		name := parts[1]
-		// Extract com.docker.compose.project from labels
-		for _, label := range strings.Split(labels, ",") {
-			if strings.HasPrefix(label, "com.docker.compose.project=") {
-				project := strings.TrimPrefix(label, "com.docker.compose.project=")
-				result[project] = append(result[project], name)
-				break
-			}
-		}
-	}
-	return result, nil
+	return CurrentRuntime().RunningContainers(ctx)
 }
 
-// CreateNetwork creates the traefik Docker network if it doesn't exist.
+// CreateNetwork creates the traefik network if it doesn't exist.
 func CreateNetwork(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "docker", "network", "create", "traefik")
+	cmd := exec.CommandContext(ctx, CurrentRuntime().Binary(), "network", "create", "traefik")
 	// Ignore error if network already exists
 	_ = cmd.Run()
 	return nil
@@ -218,11 +283,12 @@ func buildComposeArgs(name string, files []string) []string {
 }
 
 func run(ctx context.Context, dir string, composeArgs ...string) error {
+	bin := CurrentRuntime().Binary()
 	args := append([]string{"compose", "-p", "devinfra"}, composeArgs...)
-	ui.Info("Running: docker %s", strings.Join(args, " "))
-	cmd := exec.CommandContext(ctx, "docker", args...)
+	ui.Info("Running: %s %s", bin, strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Dir = dir
-	cmd.Env = infraEnv()
+	cmd.Env = config.Current().InfraEnv()
 	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -230,39 +296,49 @@ func run(ctx context.Context, dir string, composeArgs ...string) error {
 
 func runAttached(ctx context.Context, dir string, composeArgs ...string) error {
 	args := append([]string{"compose", "-p", "devinfra"}, composeArgs...)
-	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd := exec.CommandContext(ctx, CurrentRuntime().Binary(), args...)
 	cmd.Dir = dir
-	cmd.Env = infraEnv()
+	cmd.Env = config.Current().InfraEnv()
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 	return cmd.Run()
 }
 
-// infraEnv returns the environment for infra (devinfra) compose commands,
-// including DNS_PORT and CF_DNS_API_TOKEN when remote is enabled.
-func infraEnv() []string {
-	env := append(os.Environ(), "DNS_PORT="+config.DNSPort())
-	if r := config.Remote(); r.Enabled && r.CloudflareToken != "" {
-		env = append(env, "CF_DNS_API_TOKEN="+r.CloudflareToken)
+// outputKey is the context key for WithOutput.
+type outputKey struct{}
+
+// WithOutput returns a context under which project compose commands write
+// their progress output to w instead of stderr, so concurrent runs can be
+// told apart.
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// Output returns the writer set by WithOutput, or stderr.
+func Output(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(outputKey{}).(io.Writer); ok {
+		return w
 	}
-	return env
+	return os.Stderr
 }
 
 func runRaw(ctx context.Context, dir string, args ...string) error {
-	ui.Info("Running: docker %s", strings.Join(args, " "))
-	cmd := exec.CommandContext(ctx, "docker", args...)
+	bin := CurrentRuntime().Binary()
+	out := Output(ctx)
+	ui.InfoTo(out, "Running: %s %s", bin, strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Dir = dir
-	cmd.Env = append(os.Environ(), fmt.Sprintf("DNS_PORT=%s", config.DNSPort()))
-	cmd.Stdout = os.Stderr // Progress output goes to stderr
-	cmd.Stderr = os.Stderr
+	cmd.Env = config.Current().ComposeEnv()
+	cmd.Stdout = out // Progress output goes to stderr
+	cmd.Stderr = out
 	return cmd.Run()
 }
 
 func runRawAttached(ctx context.Context, dir string, args ...string) error {
-	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd := exec.CommandContext(ctx, CurrentRuntime().Binary(), args...)
 	cmd.Dir = dir
-	cmd.Env = append(os.Environ(), fmt.Sprintf("DNS_PORT=%s", config.DNSPort()))
+	cmd.Env = config.Current().ComposeEnv()
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
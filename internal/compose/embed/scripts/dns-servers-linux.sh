@@ -0,0 +1,43 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+info()  { echo -e "\033[0;36m[INFO]\033[0m $*"; }
+ok()    { echo -e "\033[0;32m[OK]\033[0m $*"; }
+
+# The default Linux setup resolves through a host dnsmasq rather than the
+# container's, so its config gets the same upstream servers and forwarding
+# rules. Whichever variant setup-linux.sh installed is rewritten.
+if [ -f /etc/dnsmasq.d/test-domain.conf ]; then
+  info "Updating /etc/dnsmasq.d/test-domain.conf..."
+  sudo tee /etc/dnsmasq.d/test-domain.conf > /dev/null <<'EOF'
+address=/{{.TLD}}/127.0.0.1
+{{- range .DNSForward}}
+server=/{{.Domain}}/{{.Server}}
+{{- end}}
+{{- range .DNSUpstream}}
+server={{.}}
+{{- end}}
+EOF
+  sudo systemctl restart dnsmasq
+  ok "Host dnsmasq now uses the configured DNS servers."
+
+elif [ -f /etc/NetworkManager/dnsmasq.d/test-domain.conf ]; then
+  info "Updating /etc/NetworkManager/dnsmasq.d/test-domain.conf..."
+  sudo tee /etc/NetworkManager/dnsmasq.d/test-domain.conf > /dev/null <<'EOF'
+address=/{{.TLD}}/127.0.0.1
+{{- range .DNSForward}}
+server=/{{.Domain}}/{{.Server}}
+{{- end}}
+EOF
+  sudo tee /etc/systemd/resolved.conf.d/test-dns.conf > /dev/null <<'EOF'
+[Resolve]
+DNS=127.0.0.2
+Domains=~{{.TLD}}{{range .DNSForward}} ~{{.Domain}}{{end}}
+EOF
+  sudo systemctl restart NetworkManager
+  sudo systemctl restart systemd-resolved
+  ok "NetworkManager's dnsmasq now forwards the configured domains; other lookups use the network's DNS servers."
+
+else
+  ok "No host dnsmasq config from 'di init' found; nothing to update."
+fi
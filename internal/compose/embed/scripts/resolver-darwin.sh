@@ -0,0 +1,16 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+info()  { echo -e "\033[0;36m[INFO]\033[0m $*"; }
+ok()    { echo -e "\033[0;32m[OK]\033[0m $*"; }
+
+DNS_PORT="${DNS_PORT:-5354}"
+
+info "Pointing /etc/resolver/{{.TLD}} at port $DNS_PORT..."
+
+sudo mkdir -p /etc/resolver
+printf "nameserver 127.0.0.1\nport %s\n" "$DNS_PORT" | sudo tee /etc/resolver/{{.TLD}} > /dev/null
+sudo dscacheutil -flushcache
+sudo killall -HUP mDNSResponder 2>/dev/null || true
+
+ok "Resolver updated."
@@ -0,0 +1,28 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+info()  { echo -e "\033[0;36m[INFO]\033[0m $*"; }
+ok()    { echo -e "\033[0;32m[OK]\033[0m $*"; }
+
+DNS_PORT="${DNS_PORT:-5354}"
+OLD_DNS_PORT="${OLD_DNS_PORT:-5354}"
+
+# The default Linux setup resolves .{{.TLD}} with the host's dnsmasq, which
+# doesn't go through the container port. Only systemd-resolved drop-ins that
+# forward to the dnsmasq container (DNS=127.0.0.1:<port>) need rewriting.
+changed=0
+for f in /etc/systemd/resolved.conf.d/*.conf; do
+  [ -f "$f" ] || continue
+  if grep -q "^DNS=127\.0\.0\.1:${OLD_DNS_PORT}\b" "$f"; then
+    info "Updating $f..."
+    sudo sed -i "s/^DNS=127\.0\.0\.1:${OLD_DNS_PORT}\b/DNS=127.0.0.1:${DNS_PORT}/" "$f"
+    changed=1
+  fi
+done
+
+if [ "$changed" -eq 1 ]; then
+  sudo systemctl restart systemd-resolved
+  ok "systemd-resolved now forwards .{{.TLD}} to port $DNS_PORT."
+else
+  ok "No systemd-resolved drop-in forwards to port $OLD_DNS_PORT; nothing to update."
+fi
@@ -31,8 +31,12 @@ EOF
   sudo apt-get install -y -qq dnsmasq
   sudo tee /etc/dnsmasq.d/test-domain.conf > /dev/null <<'EOF'
 address=/{{.TLD}}/127.0.0.1
-server=8.8.8.8
-server=8.8.4.4
+{{- range .DNSForward}}
+server=/{{.Domain}}/{{.Server}}
+{{- end}}
+{{- range .DNSUpstream}}
+server={{.}}
+{{- end}}
 EOF
   sudo systemctl restart systemd-resolved
   sudo systemctl enable --now dnsmasq
@@ -48,20 +52,23 @@ listen-address=127.0.0.2
 bind-interfaces
 EOF
 
+  # Other lookups keep going to the network's DNS servers
   sudo tee /etc/NetworkManager/dnsmasq.d/test-domain.conf > /dev/null <<'EOF'
 address=/{{.TLD}}/127.0.0.1
+{{- range .DNSForward}}
+server=/{{.Domain}}/{{.Server}}
+{{- end}}
 EOF
 
   sudo mkdir -p /etc/systemd/resolved.conf.d
   sudo tee /etc/systemd/resolved.conf.d/test-dns.conf > /dev/null <<'EOF'
 [Resolve]
 DNS=127.0.0.2
-Domains=~{{.TLD}}
+Domains=~{{.TLD}}{{range .DNSForward}} ~{{.Domain}}{{end}}
 EOF
 
   sudo systemctl restart NetworkManager
   sudo systemctl restart systemd-resolved
 fi
 
-info "Note: On Linux, host-mode projects require 'extra_hosts: host.docker.internal:host-gateway'"
 ok "Linux setup complete."
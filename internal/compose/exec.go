@@ -0,0 +1,78 @@
+package compose
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"github.com/heysarver/devinfra/internal/config"
+)
+
+// ExecOptions controls how a command is run in a project container.
+type ExecOptions struct {
+	TTY  bool   // allocate a pseudo-terminal; stdin is attached either way
+	User string // run as this user instead of the image's default
+}
+
+// ProjectExec runs command in the running container of a project's service,
+// attached to the terminal. An empty command runs sh.
+func ProjectExec(ctx context.Context, name, dir string, composeFiles []string, service string, command []string, opts ExecOptions) error {
+	if len(command) == 0 {
+		command = []string{"sh"}
+	}
+	args := buildComposeArgs(name, composeFiles)
+	args = append(args, allProfileArgs(dir, composeFiles)...)
+	args = append(args, "exec")
+	args = append(args, opts.args()...)
+	args = append(args, service)
+	return runInteractive(ctx, dir, append(args, command...)...)
+}
+
+// ProjectRun runs command in a new one-off container of a project's
+// service, removed when it exits. An empty command runs the service's
+// default command.
+func ProjectRun(ctx context.Context, name, dir string, composeFiles []string, service string, command []string, opts ExecOptions) error {
+	args := buildComposeArgs(name, composeFiles)
+	args = append(args, allProfileArgs(dir, composeFiles)...)
+	args = append(args, "run", "--rm")
+	args = append(args, opts.args()...)
+	args = append(args, service)
+	return runInteractive(ctx, dir, append(args, command...)...)
+}
+
+// ProjectCompose runs an arbitrary compose command for a project, e.g.
+// "ps" or "pull", with its full file list and the given profiles enabled.
+func ProjectCompose(ctx context.Context, name, dir string, composeFiles, profiles, composeArgs []string) error {
+	args := buildComposeArgs(name, composeFiles)
+	args = append(args, profileArgs(profiles)...)
+	return runInteractive(ctx, dir, append(args, composeArgs...)...)
+}
+
+func (o ExecOptions) args() []string {
+	var args []string
+	if !o.TTY {
+		args = append(args, "-T")
+	}
+	if o.User != "" {
+		args = append(args, "--user", o.User)
+	}
+	return args
+}
+
+// runInteractive runs a compose command that owns the terminal. Interrupts
+// are left to the child, which forwards them to the container, rather than
+// ending di and orphaning the session.
+func runInteractive(ctx context.Context, dir string, args ...string) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	cmd := exec.CommandContext(ctx, CurrentRuntime().Binary(), args...)
+	cmd.Dir = dir
+	cmd.Env = config.Current().ComposeEnv()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
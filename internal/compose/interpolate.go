@@ -0,0 +1,179 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// projectEnv returns the variables compose interpolates for a project: the
+// project directory's .env file, overridden by the process environment.
+func projectEnv(dir string) map[string]string {
+	env := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join(dir, ".env")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			line = strings.TrimPrefix(line, "export ")
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			v = strings.TrimSpace(v)
+			if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+				v = v[1 : len(v)-1]
+			}
+			env[strings.TrimSpace(k)] = v
+		}
+	}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// interpolateNode expands variable references in every scalar value under n.
+// Mapping keys are left alone, as compose does.
+func interpolateNode(n *yaml.Node, env map[string]string) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(n.Value, "$") {
+			return nil
+		}
+		v, err := interpolate(n.Value, env)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		n.Value = v
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			if err := interpolateNode(n.Content[i], env); err != nil {
+				return err
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			if err := interpolateNode(c, env); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// interpolate expands $VAR and ${VAR} references in s like docker compose,
+// including the ${VAR:-default}, ${VAR-default}, ${VAR:?error},
+// ${VAR?error}, ${VAR:+alt} and ${VAR+alt} forms. $$ is a literal $. Unset
+// variables expand to an empty string.
+func interpolate(s string, env map[string]string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("invalid interpolation format in %q: missing closing brace", s)
+			}
+			v, err := expandBraced(s[i+2:end], env)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			i = end
+		case isNameByte(next, true):
+			j := i + 1
+			for j < len(s) && isNameByte(s[j], false) {
+				j++
+			}
+			b.WriteString(env[s[i+1:j]])
+			i = j - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// expandBraced expands the contents of a ${...} reference.
+func expandBraced(expr string, env map[string]string) (string, error) {
+	n := 0
+	for n < len(expr) && isNameByte(expr[n], n == 0) {
+		n++
+	}
+	name, op := expr[:n], expr[n:]
+	if name == "" {
+		return "", fmt.Errorf("invalid interpolation format ${%s}", expr)
+	}
+	val, set := env[name]
+
+	switch {
+	case op == "":
+		return val, nil
+	case strings.HasPrefix(op, ":-"):
+		if val != "" {
+			return val, nil
+		}
+		return interpolate(op[2:], env)
+	case strings.HasPrefix(op, "-"):
+		if set {
+			return val, nil
+		}
+		return interpolate(op[1:], env)
+	case strings.HasPrefix(op, ":+"):
+		if val == "" {
+			return "", nil
+		}
+		return interpolate(op[2:], env)
+	case strings.HasPrefix(op, "+"):
+		if !set {
+			return "", nil
+		}
+		return interpolate(op[1:], env)
+	case strings.HasPrefix(op, ":?"), strings.HasPrefix(op, "?"):
+		if val != "" || (set && op[0] == '?') {
+			return val, nil
+		}
+		msg, err := interpolate(strings.TrimPrefix(strings.TrimPrefix(op, ":"), "?"), env)
+		if err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("required variable %s is missing a value: %s", name, msg)
+	}
+	return "", fmt.Errorf("invalid interpolation format ${%s}", expr)
+}
+
+// matchingBrace returns the index of the } closing a ${ whose contents start
+// at i, allowing nested ${...} in defaults.
+func matchingBrace(s string, i int) int {
+	depth := 1
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
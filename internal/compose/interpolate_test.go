@@ -0,0 +1,36 @@
+package compose
+
+import "testing"
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"PORT": "3000", "EMPTY": ""}
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "$PORT", want: "3000"},
+		{in: "${PORT}:${PORT}", want: "3000:3000"},
+		{in: "${MISSING}", want: ""},
+		{in: "${EMPTY:-80}", want: "80"},
+		{in: "${EMPTY-80}", want: ""},
+		{in: "${MISSING-80}", want: "80"},
+		{in: "${MISSING:-${PORT}}", want: "3000"},
+		{in: "${PORT:+set}", want: "set"},
+		{in: "${EMPTY:+set}", want: ""},
+		{in: "$$PORT", want: "$PORT"},
+		{in: "${MISSING:?must be set}", wantErr: true},
+		{in: "${EMPTY?must be set}", want: ""},
+		{in: "${PORT", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := interpolate(tt.in, env)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("interpolate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("interpolate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
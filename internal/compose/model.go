@@ -0,0 +1,370 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rawFile is the part of a compose file the model loader reads.
+type rawFile struct {
+	Include  []yaml.Node           `yaml:"include"`
+	Services map[string]rawService `yaml:"services"`
+}
+
+// rawService is a service definition before extends and merging. Fields
+// with several accepted shapes are kept as nodes and parsed by hand.
+type rawService struct {
+	Ports    []yaml.Node `yaml:"ports"`
+	Expose   []yaml.Node `yaml:"expose"`
+	Labels   yaml.Node   `yaml:"labels"`
+	Profiles []string    `yaml:"profiles"`
+	Extends  yaml.Node   `yaml:"extends"`
+}
+
+// serviceSpec is a service definition reduced to what detection needs.
+type serviceSpec struct {
+	ports     []int // container ports from ports
+	published []PublishedPort
+	expose    []int
+	labels    map[string]string
+	profiles  []string
+}
+
+// merge overlays o onto s following compose's merge rules: port lists are
+// concatenated, labels are merged by key, and profiles are replaced.
+func (s *serviceSpec) merge(o *serviceSpec) {
+	for _, p := range o.ports {
+		if !slices.Contains(s.ports, p) {
+			s.ports = append(s.ports, p)
+		}
+	}
+	for _, p := range o.published {
+		if !slices.Contains(s.published, p) {
+			s.published = append(s.published, p)
+		}
+	}
+	for _, p := range o.expose {
+		if !slices.Contains(s.expose, p) {
+			s.expose = append(s.expose, p)
+		}
+	}
+	for k, v := range o.labels {
+		if s.labels == nil {
+			s.labels = make(map[string]string)
+		}
+		s.labels[k] = v
+	}
+	if o.profiles != nil {
+		s.profiles = o.profiles
+	}
+}
+
+func (s *serviceSpec) clone() *serviceSpec {
+	c := &serviceSpec{
+		ports:     slices.Clone(s.ports),
+		published: slices.Clone(s.published),
+		expose:    slices.Clone(s.expose),
+		profiles:  slices.Clone(s.profiles),
+	}
+	if s.labels != nil {
+		c.labels = make(map[string]string, len(s.labels))
+		for k, v := range s.labels {
+			c.labels[k] = v
+		}
+	}
+	return c
+}
+
+// modelLoader reads the compose files of one project. Files are parsed once
+// and shared between extends and include references.
+type modelLoader struct {
+	env       map[string]string
+	raw       map[string]*rawFile // interpolated files by absolute path
+	including map[string]bool     // include chain, for cycle detection
+}
+
+// loadModel reads and merges the compose files (relative to dir) in order,
+// as 'docker compose -f a -f b' would, and returns the resulting services.
+func loadModel(dir string, files []string) (map[string]*serviceSpec, error) {
+	return newModelLoader(projectEnv(dir)).loadFiles(dir, files)
+}
+
+// newModelLoader returns a loader interpolating variables from env.
+func newModelLoader(env map[string]string) *modelLoader {
+	return &modelLoader{
+		env:       env,
+		raw:       make(map[string]*rawFile),
+		including: make(map[string]bool),
+	}
+}
+
+func (l *modelLoader) loadFiles(dir string, files []string) (map[string]*serviceSpec, error) {
+	merged := make(map[string]*serviceSpec)
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(dir, f)
+		}
+		services, err := l.loadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for name, svc := range services {
+			if base, ok := merged[name]; ok {
+				base.merge(svc)
+			} else {
+				merged[name] = svc.clone()
+			}
+		}
+	}
+	return merged, nil
+}
+
+// loadFile returns the fully resolved services of one file, including the
+// services of the files it includes.
+func (l *modelLoader) loadFile(path string) (map[string]*serviceSpec, error) {
+	if l.including[path] {
+		return nil, fmt.Errorf("include cycle through %s", path)
+	}
+	l.including[path] = true
+	defer delete(l.including, path)
+
+	rf, err := l.readRaw(path)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]*serviceSpec)
+	for _, inc := range rf.Include {
+		paths, err := includePaths(inc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		included, err := l.loadFiles(filepath.Dir(path), paths)
+		if err != nil {
+			return nil, err
+		}
+		for name, svc := range included {
+			if _, ok := services[name]; ok {
+				return nil, fmt.Errorf("%s: service %q is defined by more than one include", path, name)
+			}
+			services[name] = svc
+		}
+	}
+
+	for name := range rf.Services {
+		if _, ok := services[name]; ok {
+			return nil, fmt.Errorf("%s: service %q conflicts with an included service", path, name)
+		}
+		svc, err := l.resolveService(path, name, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		services[name] = svc
+	}
+	return services, nil
+}
+
+// readRaw parses and interpolates a compose file.
+func (l *modelLoader) readRaw(path string) (*rawFile, error) {
+	if rf, ok := l.raw[path]; ok {
+		return rf, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading compose file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing compose file %s: %w", filepath.Base(path), err)
+	}
+	if err := interpolateNode(&doc, l.env); err != nil {
+		return nil, fmt.Errorf("interpolating %s: %w", filepath.Base(path), err)
+	}
+	rf := &rawFile{}
+	if len(doc.Content) > 0 {
+		if err := doc.Content[0].Decode(rf); err != nil {
+			return nil, fmt.Errorf("parsing compose file %s: %w", filepath.Base(path), err)
+		}
+	}
+	l.raw[path] = rf
+	return rf, nil
+}
+
+// resolveService returns a service from the file at path with its extends
+// chain applied. seen guards against extends cycles.
+func (l *modelLoader) resolveService(path, name string, seen map[string]bool) (*serviceSpec, error) {
+	ref := path + "#" + name
+	if seen[ref] {
+		return nil, fmt.Errorf("%s: service %q extends itself", filepath.Base(path), name)
+	}
+	seen[ref] = true
+
+	rf, err := l.readRaw(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := rf.Services[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: service %q not found", filepath.Base(path), name)
+	}
+	svc := raw.spec()
+
+	baseName, baseFile := extendsTarget(raw.Extends)
+	if baseName == "" {
+		return svc, nil
+	}
+	basePath := path
+	if baseFile != "" {
+		basePath = filepath.Join(filepath.Dir(path), baseFile)
+	}
+	base, err := l.resolveService(basePath, baseName, seen)
+	if err != nil {
+		return nil, err
+	}
+	base = base.clone()
+	base.merge(svc)
+	return base, nil
+}
+
+// extendsTarget reads "extends: name" or "extends: {service, file}".
+func extendsTarget(n yaml.Node) (service, file string) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Value, ""
+	case yaml.MappingNode:
+		var ext struct {
+			Service string `yaml:"service"`
+			File    string `yaml:"file"`
+		}
+		if n.Decode(&ext) == nil {
+			return ext.Service, ext.File
+		}
+	}
+	return "", ""
+}
+
+// includePaths reads an include entry, either a path or a mapping whose path
+// is a string or a list of files merged together.
+func includePaths(n yaml.Node) ([]string, error) {
+	if n.Kind == yaml.ScalarNode {
+		return []string{n.Value}, nil
+	}
+	var inc struct {
+		Path yaml.Node `yaml:"path"`
+	}
+	if err := n.Decode(&inc); err != nil {
+		return nil, fmt.Errorf("invalid include: %w", err)
+	}
+	switch inc.Path.Kind {
+	case yaml.ScalarNode:
+		return []string{inc.Path.Value}, nil
+	case yaml.SequenceNode:
+		var paths []string
+		if err := inc.Path.Decode(&paths); err != nil {
+			return nil, fmt.Errorf("invalid include path: %w", err)
+		}
+		return paths, nil
+	}
+	return nil, fmt.Errorf("include at line %d has no path", n.Line)
+}
+
+func (r rawService) spec() *serviceSpec {
+	s := &serviceSpec{profiles: r.Profiles}
+	for _, n := range r.Ports {
+		if p := extractPort(n); p > 0 && !slices.Contains(s.ports, p) {
+			s.ports = append(s.ports, p)
+		}
+		for _, pub := range parsePublished(n) {
+			if !slices.Contains(s.published, pub) {
+				s.published = append(s.published, pub)
+			}
+		}
+	}
+	for _, n := range r.Expose {
+		if p := parseShortPort(n.Value); p > 0 && !slices.Contains(s.expose, p) {
+			s.expose = append(s.expose, p)
+		}
+	}
+	s.labels = parseLabels(r.Labels)
+	return s
+}
+
+// parseLabels reads labels in either list ("key=value") or mapping form.
+func parseLabels(n yaml.Node) map[string]string {
+	labels := make(map[string]string)
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			labels[n.Content[i].Value] = n.Content[i+1].Value
+		}
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			k, v, _ := strings.Cut(c.Value, "=")
+			labels[k] = v
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// traefikPortLabel matches the label pinning a Traefik service's backend port.
+var traefikPortLabel = regexp.MustCompile(`^traefik\.http\.services\.[^.]+\.loadbalancer\.server\.port$`)
+
+// nonHTTPPorts are well-known ports of databases, brokers, and caches, which
+// are rarely what a service should be routed on over HTTPS.
+var nonHTTPPorts = map[int]bool{
+	22: true, 25: true, 53: true, 1433: true, 1521: true, 1883: true, 2181: true,
+	3306: true, 4222: true, 5432: true, 5672: true, 6379: true, 9042: true,
+	9092: true, 11211: true, 27017: true,
+}
+
+// candidates ranks every port the service may serve HTTP on.
+func (s *serviceSpec) candidates() []PortCandidate {
+	var out []PortCandidate
+	add := func(port int, conf Confidence, source string) {
+		if nonHTTPPorts[port] && conf < ConfidenceHigh {
+			conf = ConfidenceLow
+		}
+		for i := range out {
+			if out[i].Port == port {
+				if conf > out[i].Confidence {
+					out[i].Confidence, out[i].Source = conf, source
+				}
+				return
+			}
+		}
+		out = append(out, PortCandidate{Port: port, Confidence: conf, Source: source})
+	}
+
+	var labelKeys []string
+	for k := range s.labels {
+		if traefikPortLabel.MatchString(k) {
+			labelKeys = append(labelKeys, k)
+		}
+	}
+	slices.Sort(labelKeys)
+	for _, k := range labelKeys {
+		if p, err := strconv.Atoi(s.labels[k]); err == nil && p > 0 {
+			add(p, ConfidenceHigh, "traefik label")
+		}
+	}
+	for _, p := range s.ports {
+		add(p, ConfidenceMedium, "ports")
+	}
+	for _, p := range s.expose {
+		add(p, ConfidenceMedium, "expose")
+	}
+
+	slices.SortStableFunc(out, func(a, b PortCandidate) int { return int(b.Confidence) - int(a.Confidence) })
+	return out
+}
@@ -1,7 +1,6 @@
 package compose
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -19,20 +18,79 @@ var ComposeFileNames = []string{
 	"docker-compose.yml",
 }
 
-// DetectedService represents a service found in a compose file.
+// DetectedService represents a service found in a project's compose files.
 type DetectedService struct {
-	Name string
-	Port int // 0 means no port exposed
+	Name       string
+	Port       int             // best candidate; 0 means no port exposed
+	Candidates []PortCandidate // every candidate port, most likely first
+	Profiles   []string        // compose profiles the service belongs to
+	Labels     map[string]string
 }
 
-// composeFile is a minimal struct for parsing compose YAML.
-type composeFile struct {
-	Services map[string]serviceDef `yaml:"services"`
+// Confidence is how likely a candidate port is the one to route HTTP to.
+type Confidence int
+
+const (
+	ConfidenceLow    Confidence = iota // exposed, but a well-known non-HTTP port
+	ConfidenceMedium                   // published or exposed
+	ConfidenceHigh                     // pinned by an existing Traefik label
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceHigh:
+		return "high"
+	case ConfidenceMedium:
+		return "medium"
+	}
+	return "low"
 }
 
-// serviceDef captures only what we need from a service definition.
-type serviceDef struct {
-	Ports []yaml.Node `yaml:"ports"`
+// PortCandidate is a container port a service may serve HTTP on.
+type PortCandidate struct {
+	Port       int
+	Confidence Confidence
+	Source     string // "traefik label", "ports", or "expose"
+}
+
+// TraefikObjects lists the Traefik HTTP routers, services, and middlewares a
+// service's labels define, each sorted by name.
+type TraefikObjects struct {
+	Routers     []string
+	Services    []string
+	Middlewares []string
+}
+
+// Traefik returns the Traefik objects defined by the service's labels.
+func (d DetectedService) Traefik() TraefikObjects {
+	var t TraefikObjects
+	for key := range d.Labels {
+		parts := strings.SplitN(key, ".", 5)
+		if len(parts) < 4 || parts[0] != "traefik" || parts[1] != "http" {
+			continue
+		}
+		switch parts[2] {
+		case "routers":
+			t.Routers = appendUnique(t.Routers, parts[3])
+		case "services":
+			t.Services = appendUnique(t.Services, parts[3])
+		case "middlewares":
+			t.Middlewares = appendUnique(t.Middlewares, parts[3])
+		}
+	}
+	sort.Strings(t.Routers)
+	sort.Strings(t.Services)
+	sort.Strings(t.Middlewares)
+	return t
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
 }
 
 // FindComposeFile scans a directory for a Docker Compose file
@@ -47,26 +105,41 @@ func FindComposeFile(dir string) string {
 	return ""
 }
 
-// ParseServices reads a compose file and extracts service names and ports.
-func ParseServices(dir, composeFileName string) ([]DetectedService, error) {
-	path := filepath.Join(dir, composeFileName)
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("reading compose file: %w", err)
+// FindOverrideFile returns the override file docker compose would load next
+// to base by default (e.g. compose.override.yaml), or "" if there is none.
+func FindOverrideFile(dir, base string) string {
+	stem := strings.TrimSuffix(strings.TrimSuffix(base, ".yaml"), ".yml")
+	for _, ext := range []string{".yaml", ".yml"} {
+		name := stem + ".override" + ext
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return name
+		}
 	}
+	return ""
+}
 
-	var cf composeFile
-	if err := yaml.Unmarshal(data, &cf); err != nil {
-		return nil, fmt.Errorf("parsing compose file: %w", err)
+// ParseServices loads the compose files (relative to dir) the way docker
+// compose does, merging them in order and resolving include, extends, and
+// ${VAR} interpolation from the environment and the project's .env, and
+// returns each service with its candidate HTTP ports.
+func ParseServices(dir string, files []string) ([]DetectedService, error) {
+	specs, err := loadModel(dir, files)
+	if err != nil {
+		return nil, err
 	}
 
-	var services []DetectedService
-	for name, svc := range cf.Services {
-		port := 0
-		if len(svc.Ports) > 0 {
-			port = extractFirstPort(svc.Ports[0])
+	services := make([]DetectedService, 0, len(specs))
+	for name, spec := range specs {
+		svc := DetectedService{
+			Name:       name,
+			Candidates: spec.candidates(),
+			Profiles:   spec.profiles,
+			Labels:     spec.labels,
+		}
+		if len(svc.Candidates) > 0 {
+			svc.Port = svc.Candidates[0].Port
 		}
-		services = append(services, DetectedService{Name: name, Port: port})
+		services = append(services, svc)
 	}
 
 	sort.Slice(services, func(i, j int) bool {
@@ -76,12 +149,15 @@ func ParseServices(dir, composeFileName string) ([]DetectedService, error) {
 	return services, nil
 }
 
-// extractFirstPort extracts the container port from a compose port definition.
+// extractPort extracts the container port from a compose port definition.
 // Supports short form ("8000:8000", "8000", "127.0.0.1:8000:8000")
-// and long form (mapping with target key).
-func extractFirstPort(node yaml.Node) int {
+// and long form (mapping with target key). UDP ports are skipped.
+func extractPort(node yaml.Node) int {
 	switch node.Kind {
 	case yaml.ScalarNode:
+		if strings.HasSuffix(node.Value, "/udp") {
+			return 0
+		}
 		return parseShortPort(node.Value)
 	case yaml.MappingNode:
 		return parseLongPort(node)
@@ -122,16 +198,18 @@ func parseShortPort(s string) int {
 //	target: 8000
 //	published: "8080"
 func parseLongPort(node yaml.Node) int {
+	port := 0
 	for i := 0; i+1 < len(node.Content); i += 2 {
 		key := node.Content[i]
 		val := node.Content[i+1]
-		if key.Value == "target" {
-			port, err := strconv.Atoi(val.Value)
-			if err != nil {
+		switch key.Value {
+		case "target":
+			port, _ = strconv.Atoi(val.Value)
+		case "protocol":
+			if val.Value == "udp" {
 				return 0
 			}
-			return port
 		}
 	}
-	return 0
+	return port
 }
@@ -3,6 +3,7 @@ package compose
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -105,7 +106,7 @@ services:
 				t.Fatalf("writing compose file: %v", err)
 			}
 
-			got, err := ParseServices(dir, tt.filename)
+			got, err := ParseServices(dir, []string{tt.filename})
 			if err != nil {
 				t.Fatalf("ParseServices: %v", err)
 			}
@@ -125,6 +126,140 @@ services:
 	}
 }
 
+func TestParseServicesModel(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string // written to the project dir
+		load  []string
+		want  map[string][]PortCandidate
+	}{
+		{
+			name: "override files merge ports and expose",
+			files: map[string]string{
+				"compose.yaml": `
+services:
+  web:
+    ports: ["3000:3000"]
+  db:
+    image: postgres
+    ports: ["5432:5432"]
+`,
+				"compose.override.yaml": `
+services:
+  web:
+    expose: ["9229"]
+`,
+			},
+			load: []string{"compose.yaml", "compose.override.yaml"},
+			want: map[string][]PortCandidate{
+				"web": {{3000, ConfidenceMedium, "ports"}, {9229, ConfidenceMedium, "expose"}},
+				"db":  {{5432, ConfidenceLow, "ports"}},
+			},
+		},
+		{
+			name: "interpolation from .env with defaults",
+			files: map[string]string{
+				".env": "APP_PORT=8000\n",
+				"compose.yaml": `
+services:
+  app:
+    ports: ["${HOST_PORT:-80}:${APP_PORT}"]
+  admin:
+    ports:
+      - target: ${ADMIN_PORT-9000}
+`,
+			},
+			load: []string{"compose.yaml"},
+			want: map[string][]PortCandidate{
+				"app":   {{8000, ConfidenceMedium, "ports"}},
+				"admin": {{9000, ConfidenceMedium, "ports"}},
+			},
+		},
+		{
+			name: "traefik label outranks ports",
+			files: map[string]string{
+				"compose.yaml": `
+services:
+  api:
+    ports: ["9090:9090"]
+    labels:
+      - traefik.http.services.api.loadbalancer.server.port=8080
+`,
+			},
+			load: []string{"compose.yaml"},
+			want: map[string][]PortCandidate{
+				"api": {{8080, ConfidenceHigh, "traefik label"}, {9090, ConfidenceMedium, "ports"}},
+			},
+		},
+		{
+			name: "extends and include",
+			files: map[string]string{
+				"base.yaml": `
+services:
+  node:
+    expose: ["3000"]
+`,
+				"infra/compose.yaml": `
+services:
+  cache:
+    image: redis
+    expose: ["6379"]
+`,
+				"compose.yaml": `
+include:
+  - infra/compose.yaml
+services:
+  web:
+    extends:
+      file: base.yaml
+      service: node
+    ports: ["4000:4000"]
+  worker:
+    extends: web
+`,
+			},
+			load: []string{"compose.yaml"},
+			want: map[string][]PortCandidate{
+				"web":    {{4000, ConfidenceMedium, "ports"}, {3000, ConfidenceMedium, "expose"}},
+				"worker": {{4000, ConfidenceMedium, "ports"}, {3000, ConfidenceMedium, "expose"}},
+				"cache":  {{6379, ConfidenceLow, "expose"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("writing %s: %v", name, err)
+				}
+			}
+
+			got, err := ParseServices(dir, tt.load)
+			if err != nil {
+				t.Fatalf("ParseServices: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d services, want %d: %v", len(got), len(tt.want), got)
+			}
+			for _, svc := range got {
+				want := tt.want[svc.Name]
+				if !slices.Equal(svc.Candidates, want) {
+					t.Errorf("%s candidates = %v, want %v", svc.Name, svc.Candidates, want)
+				}
+				if len(want) > 0 && svc.Port != want[0].Port {
+					t.Errorf("%s port = %d, want %d", svc.Name, svc.Port, want[0].Port)
+				}
+			}
+		})
+	}
+}
+
 func TestFindComposeFile(t *testing.T) {
 	tests := []struct {
 		name     string
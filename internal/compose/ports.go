@@ -0,0 +1,269 @@
+package compose
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// PublishedPort is a host port a compose service publishes.
+type PublishedPort struct {
+	Service  string `json:"service"`
+	HostIP   string `json:"host_ip,omitempty"`
+	Port     int    `json:"port"`   // on the host
+	Target   int    `json:"target"` // in the container
+	Protocol string `json:"protocol"`
+}
+
+// ProjectPorts returns the host ports a project's compose files publish for
+// the services the given profiles enable, ordered by port.
+func ProjectPorts(dir string, files, profiles []string) ([]PublishedPort, error) {
+	specs, err := loadModel(dir, files)
+	if err != nil {
+		return nil, err
+	}
+	return publishedPorts(specs, profiles), nil
+}
+
+// InfraPorts returns the host ports the core infrastructure publishes.
+func InfraPorts() ([]PublishedPort, error) {
+	env := projectEnv(config.ComposeDir())
+	env["DNS_PORT"] = strconv.Itoa(config.Current().DNSPort)
+	specs, err := newModelLoader(env).loadFiles(config.ComposeDir(), []string{config.ComposeFile()})
+	if err != nil {
+		return nil, err
+	}
+	return publishedPorts(specs, nil), nil
+}
+
+func publishedPorts(specs map[string]*serviceSpec, profiles []string) []PublishedPort {
+	var ports []PublishedPort
+	for name, spec := range specs {
+		if !ProfileEnabled(spec.profiles, profiles) {
+			continue
+		}
+		for _, p := range spec.published {
+			p.Service = name
+			ports = append(ports, p)
+		}
+	}
+	slices.SortFunc(ports, func(a, b PublishedPort) int {
+		return cmp.Or(a.Port-b.Port, strings.Compare(a.Protocol, b.Protocol), strings.Compare(a.Service, b.Service))
+	})
+	return ports
+}
+
+// parsePublished returns the fixed host ports a port definition publishes.
+// Ports without a host side, or with a host range mapped to a single
+// container port, are assigned by the engine and left out.
+func parsePublished(n yaml.Node) []PublishedPort {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return parseShortPublished(n.Value)
+	case yaml.MappingNode:
+		var long struct {
+			Target    string `yaml:"target"`
+			Published string `yaml:"published"`
+			HostIP    string `yaml:"host_ip"`
+			Protocol  string `yaml:"protocol"`
+		}
+		if n.Decode(&long) != nil {
+			return nil
+		}
+		return expandPublished(long.HostIP, long.Published, long.Target, cmp.Or(long.Protocol, "tcp"))
+	}
+	return nil
+}
+
+// parseShortPublished handles "[ip:]host:container[/protocol]", where each
+// port may be a range and an IPv6 ip is bracketed.
+func parseShortPublished(s string) []PublishedPort {
+	proto := "tcp"
+	if spec, p, ok := strings.Cut(s, "/"); ok {
+		s, proto = spec, p
+	}
+	var ip string
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]:")
+		if end < 0 {
+			return nil
+		}
+		ip, s = s[1:end], s[end+2:]
+	}
+	parts := strings.Split(s, ":")
+	switch len(parts) {
+	case 2:
+		return expandPublished(ip, parts[0], parts[1], proto)
+	case 3:
+		return expandPublished(parts[0], parts[1], parts[2], proto)
+	}
+	return nil
+}
+
+func expandPublished(ip, host, target, proto string) []PublishedPort {
+	hostLo, hostHi, ok := portRange(host)
+	if !ok {
+		return nil
+	}
+	targetLo, targetHi, ok := portRange(target)
+	if !ok || targetHi-targetLo != hostHi-hostLo {
+		return nil
+	}
+	var ports []PublishedPort
+	for i := 0; hostLo+i <= hostHi; i++ {
+		ports = append(ports, PublishedPort{HostIP: ip, Port: hostLo + i, Target: targetLo + i, Protocol: proto})
+	}
+	return ports
+}
+
+// portRange parses "8000" or "8000-8005".
+func portRange(s string) (lo, hi int, ok bool) {
+	loStr, hiStr, isRange := strings.Cut(s, "-")
+	lo, err := strconv.Atoi(loStr)
+	if err != nil || lo < 1 || lo > 65535 {
+		return 0, 0, false
+	}
+	if !isRange {
+		return lo, lo, true
+	}
+	hi, err = strconv.Atoi(hiStr)
+	if err != nil || hi < lo || hi > 65535 {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
+// PortConflict is a published port something else already holds.
+type PortConflict struct {
+	PublishedPort
+	Owner string // the container or process holding it, "" if unknown
+}
+
+func (c PortConflict) String() string {
+	owner := c.Owner
+	if owner == "" {
+		owner = "another process"
+	}
+	return fmt.Sprintf("%d/%s (%s) is in use by %s", c.Port, c.Protocol, c.Service, owner)
+}
+
+// PortConflicts returns the ports that are already bound on the host by
+// anything other than the compose project itself, which may be running.
+func PortConflicts(ctx context.Context, project string, ports []PublishedPort) []PortConflict {
+	var conflicts []PortConflict
+	var bindings []PortBinding
+	loaded := false
+	for _, p := range ports {
+		if !PortInUse(p) {
+			continue
+		}
+		if !loaded {
+			bindings, _ = CurrentRuntime().PortBindings(ctx)
+			loaded = true
+		}
+		owner, ownerProject := PortOwner(ctx, p, bindings)
+		if ownerProject == project {
+			continue
+		}
+		conflicts = append(conflicts, PortConflict{PublishedPort: p, Owner: owner})
+	}
+	return conflicts
+}
+
+// PortInUse reports whether something on the host already holds a port.
+func PortInUse(p PublishedPort) bool {
+	addr := net.JoinHostPort(p.HostIP, strconv.Itoa(p.Port))
+	if p.Protocol == "udp" {
+		c, err := net.ListenPacket("udp", addr)
+		if err == nil {
+			_ = c.Close()
+		}
+		return errors.Is(err, syscall.EADDRINUSE)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err == nil {
+		_ = l.Close()
+		return false
+	}
+	if errors.Is(err, syscall.EACCES) {
+		// A privileged port we may not bind ourselves: see if anything answers
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(p.Port)), 300*time.Millisecond)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}
+	return errors.Is(err, syscall.EADDRINUSE)
+}
+
+// PortOwner describes what holds a host port: a container from bindings,
+// with its compose project, or else a host process. It returns "" if the
+// owner can't be determined.
+func PortOwner(ctx context.Context, p PublishedPort, bindings []PortBinding) (owner, project string) {
+	for _, b := range bindings {
+		if b.Port == p.Port && b.Protocol == p.Protocol {
+			if b.Project != "" {
+				return fmt.Sprintf("container %s (project %s)", b.Container, b.Project), b.Project
+			}
+			return "container " + b.Container, ""
+		}
+	}
+	return processOwner(ctx, p), ""
+}
+
+// ssUsers matches the owning process in ss -p output.
+var ssUsers = regexp.MustCompile(`users:\(\("([^"]+)",pid=(\d+)`)
+
+// processOwner names the host process bound to a port, using lsof or, where
+// it is missing, ss. Processes of other users are only visible as root.
+func processOwner(ctx context.Context, p PublishedPort) string {
+	if _, err := exec.LookPath("lsof"); err == nil {
+		args := []string{"-nP", fmt.Sprintf("-i%s:%d", strings.ToUpper(p.Protocol), p.Port), "-Fpc"}
+		if p.Protocol == "tcp" {
+			args = append(args, "-sTCP:LISTEN")
+		}
+		out, _ := exec.CommandContext(ctx, "lsof", args...).Output()
+		var pid, command string
+		sc := bufio.NewScanner(strings.NewReader(string(out)))
+		for sc.Scan() && (pid == "" || command == "") {
+			line := sc.Text()
+			switch {
+			case strings.HasPrefix(line, "p"):
+				pid = line[1:]
+			case strings.HasPrefix(line, "c"):
+				command = line[1:]
+			}
+		}
+		if pid != "" {
+			return fmt.Sprintf("%s (pid %s)", command, pid)
+		}
+		return ""
+	}
+
+	if _, err := exec.LookPath("ss"); err == nil {
+		flags := "-Hlnpt"
+		if p.Protocol == "udp" {
+			flags = "-Hlnpu"
+		}
+		out, _ := exec.CommandContext(ctx, "ss", flags, fmt.Sprintf("sport = :%d", p.Port)).Output()
+		if m := ssUsers.FindStringSubmatch(string(out)); m != nil {
+			return fmt.Sprintf("%s (pid %s)", m[1], m[2])
+		}
+	}
+	return ""
+}
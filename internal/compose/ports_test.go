@@ -0,0 +1,60 @@
+package compose
+
+import (
+	"net"
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParsePublished(t *testing.T) {
+	tests := []struct {
+		name string
+		port string
+		want []PublishedPort
+	}{
+		{"container only", `"3000"`, nil},
+		{"host and container", `"8080:80"`, []PublishedPort{{Port: 8080, Target: 80, Protocol: "tcp"}}},
+		{"host ip", `"127.0.0.1:5432:5432"`, []PublishedPort{{HostIP: "127.0.0.1", Port: 5432, Target: 5432, Protocol: "tcp"}}},
+		{"ipv6 host ip", `"[::1]:8080:80"`, []PublishedPort{{HostIP: "::1", Port: 8080, Target: 80, Protocol: "tcp"}}},
+		{"ephemeral with ip", `"127.0.0.1::80"`, nil},
+		{"udp", `"5354:53/udp"`, []PublishedPort{{Port: 5354, Target: 53, Protocol: "udp"}}},
+		{"range", `"9000-9001:8000-8001"`, []PublishedPort{
+			{Port: 9000, Target: 8000, Protocol: "tcp"},
+			{Port: 9001, Target: 8001, Protocol: "tcp"},
+		}},
+		{"host range to one port", `"9000-9005:80"`, nil},
+		{"long form", "{target: 80, published: 8080, host_ip: 127.0.0.1}", []PublishedPort{{HostIP: "127.0.0.1", Port: 8080, Target: 80, Protocol: "tcp"}}},
+		{"long form unpublished", "{target: 80}", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.port), &doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := parsePublished(*doc.Content[0]); !slices.Equal(got, tt.want) {
+				t.Errorf("parsePublished(%s) = %+v, want %+v", tt.port, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPortInUse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+
+	p := PublishedPort{HostIP: "127.0.0.1", Port: port, Protocol: "tcp"}
+	if !PortInUse(p) {
+		t.Errorf("PortInUse(%d) = false while listening", port)
+	}
+	_ = l.Close()
+	if PortInUse(p) {
+		t.Errorf("PortInUse(%d) = true after closing", port)
+	}
+}
@@ -0,0 +1,243 @@
+package compose
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/heysarver/devinfra/internal/config"
+)
+
+// traefikAddr is Traefik's websecure entrypoint as published on the host.
+const traefikAddr = "127.0.0.1:443"
+
+// Route is a service URL served through Traefik.
+type Route struct {
+	Service string
+	URL     string
+	Port    int  // backend port, for diagnosis
+	Host    bool // served from the host rather than a container
+}
+
+// RouteCheck is the outcome of probing a route.
+type RouteCheck struct {
+	Route
+	Status int   // HTTP status of the last response, 0 if there was none
+	Err    error // why the route is not ready; nil once it is
+}
+
+// WaitRoutes requests each route through Traefik until the service behind it
+// answers, or until ctx is done. A route is ready once Traefik forwards the
+// request and gets a response that isn't a gateway error; redirects and
+// application errors, including a 404 while Traefik has a router for the
+// host, count as ready. Certificates are verified against the mkcert CA.
+// Routes are probed concurrently and their checks returned in order.
+func WaitRoutes(ctx context.Context, s *config.Settings, routes []Route) ([]RouteCheck, error) {
+	client, err := routeClient()
+	if err != nil {
+		return nil, err
+	}
+	p := &prober{
+		client:  client,
+		routers: fmt.Sprintf("https://traefik.%s/api/http/routers?per_page=1000", s.TLD),
+	}
+
+	checks := make([]RouteCheck, len(routes))
+	var wg sync.WaitGroup
+	for i, r := range routes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks[i] = waitRoute(ctx, p, r)
+		}()
+	}
+	wg.Wait()
+	return checks, nil
+}
+
+// prober requests routes through Traefik.
+type prober struct {
+	client  *http.Client
+	routers string // URL of Traefik's HTTP router list, served by the dashboard
+}
+
+func waitRoute(ctx context.Context, p *prober, r Route) RouteCheck {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		c := p.probeRoute(ctx, r)
+		if c.Err == nil {
+			return c
+		}
+		select {
+		case <-ctx.Done():
+			return c
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeRoute requests a route once.
+func (p *prober) probeRoute(ctx context.Context, r Route) RouteCheck {
+	c := RouteCheck{Route: r}
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		c.Err = diagnoseRequest(err)
+		return c
+	}
+	resp.Body.Close()
+	c.Status = resp.StatusCode
+	if resp.StatusCode == http.StatusNotFound && !p.routed(reqCtx, req.URL.Hostname()) {
+		c.Err = noRouter(r)
+		return c
+	}
+	c.Err = diagnoseResponse(r, resp.StatusCode)
+	return c
+}
+
+// routed reports whether a 404 for host could have come from the app rather
+// than Traefik: it is false only if Traefik's API lists no router that can
+// match host. Apps are free to answer 404 with any body, including Traefik's
+// own "404 page not found", so the body proves nothing. If the API can't be
+// read the route is given the benefit of the doubt.
+func (p *prober) routed(ctx context.Context, host string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.routers, nil)
+	if err != nil {
+		return true
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return true
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return true
+	}
+	var routers []struct {
+		Rule   string `json:"rule"`
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&routers); err != nil {
+		return true
+	}
+	for _, rt := range routers {
+		if rt.Status == "enabled" && ruleMayMatch(rt.Rule, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleMayMatch reports whether a router rule names host, or matches hosts in
+// a way that can't be checked here (HostRegexp, or no host matcher at all).
+func ruleMayMatch(rule, host string) bool {
+	if strings.Contains(rule, "HostRegexp(") || !strings.Contains(rule, "Host(") {
+		return true
+	}
+	return strings.Contains(rule, "`"+host+"`") || strings.Contains(rule, `"`+host+`"`)
+}
+
+// diagnoseRequest explains a request that got no HTTP response.
+func diagnoseRequest(err error) error {
+	var certErr *tls.CertificateVerificationError
+	switch {
+	case errors.As(err, &certErr):
+		return fmt.Errorf("certificate not trusted by the mkcert CA: %v; run 'di certs regen <project>'", certErr.Err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("no response from Traefik")
+	case isDialError(err):
+		return fmt.Errorf("cannot connect to Traefik on %s; is core infrastructure running? ('di up')", traefikAddr)
+	}
+	return err
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// noRouter explains a 404 for a host no Traefik router matches.
+func noRouter(r Route) error {
+	if r.Host {
+		return fmt.Errorf("404 from Traefik: no router matches this host; run 'di regenerate' to rewrite the project's dynamic config")
+	}
+	return fmt.Errorf("404 from Traefik: no router matches this host; check that the %s container is running, is on the traefik network, and has the devinfra labels ('di regenerate' rewrites them)", r.Service)
+}
+
+// diagnoseResponse returns nil if a response shows the route is up, or an
+// error explaining the gateway failure.
+func diagnoseResponse(r Route, status int) error {
+	where := fmt.Sprintf("port %d in the %s container", r.Port, r.Service)
+	if r.Host {
+		where = fmt.Sprintf("host port %d", r.Port)
+	}
+	switch {
+	case status == http.StatusBadGateway:
+		return fmt.Errorf("502 from Traefik: nothing answered on %s; check the service listens there on all interfaces", where)
+	case status == http.StatusServiceUnavailable:
+		return fmt.Errorf("503 from Traefik: no healthy backend for %s", r.Service)
+	case status == http.StatusGatewayTimeout:
+		return fmt.Errorf("504 from Traefik: %s did not respond in time", where)
+	}
+	return nil
+}
+
+// routeClient returns an HTTPS client that sends every request to Traefik on
+// the host, so probes don't depend on the system resolver being set up for
+// the TLD, and trusts the mkcert CA. Redirects are not followed.
+func routeClient() (*http.Client, error) {
+	pool, err := mkcertCA()
+	if err != nil {
+		return nil, err
+	}
+	var d net.Dialer
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "tcp", traefikAddr)
+		},
+		TLSClientConfig:   &tls.Config{RootCAs: pool},
+		DisableKeepAlives: true,
+	}
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}, nil
+}
+
+// mkcertCA returns a pool holding mkcert's root CA.
+func mkcertCA() (*x509.CertPool, error) {
+	out, err := exec.Command("mkcert", "-CAROOT").Output()
+	if err != nil {
+		return nil, fmt.Errorf("locating the mkcert CA: %w", err)
+	}
+	path := filepath.Join(strings.TrimSpace(string(out)), "rootCA.pem")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the mkcert CA: %w; run 'mkcert -install'", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+	return pool, nil
+}
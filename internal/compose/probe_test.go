@@ -0,0 +1,67 @@
+package compose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProbeRoute(t *testing.T) {
+	const routed = `[{"rule":"Host(` + "`127.0.0.1`" + `)","status":"enabled"}]`
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		routers string // Traefik's router list; empty if the API is unreachable
+		wantErr string
+	}{
+		{"ok", http.StatusOK, "hello", routed, ""},
+		{"redirect", http.StatusFound, "", routed, ""},
+		{"app 404", http.StatusNotFound, "<h1>Not Found</h1>", routed, ""},
+		{"app 404 with traefik's body", http.StatusNotFound, "404 page not found\n", routed, ""},
+		{"no router", http.StatusNotFound, "404 page not found\n", `[{"rule":"Host(` + "`other.test`" + `)","status":"enabled"}]`, "no router matches"},
+		{"disabled router", http.StatusNotFound, "404 page not found\n", `[{"rule":"Host(` + "`127.0.0.1`" + `)","status":"disabled"}]`, "no router matches"},
+		{"host regexp router", http.StatusNotFound, "404 page not found\n", `[{"rule":"HostRegexp(` + "`.+`" + `)","status":"enabled"}]`, ""},
+		{"api unreachable", http.StatusNotFound, "404 page not found\n", "", ""},
+		{"bad gateway", http.StatusBadGateway, "Bad Gateway", routed, "nothing answered on port 3000"},
+		{"unavailable", http.StatusServiceUnavailable, "no available server", routed, "no healthy backend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/http/routers" {
+					if tt.routers == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write([]byte(tt.routers))
+					return
+				}
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "/login")
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			client := srv.Client()
+			client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+			p := &prober{client: client, routers: srv.URL + "/api/http/routers"}
+
+			c := p.probeRoute(context.Background(), Route{Service: "web", URL: srv.URL, Port: 3000})
+			if c.Status != tt.status {
+				t.Errorf("Status = %d, want %d", c.Status, tt.status)
+			}
+			switch {
+			case tt.wantErr == "" && c.Err != nil:
+				t.Errorf("unexpected error: %v", c.Err)
+			case tt.wantErr != "" && (c.Err == nil || !strings.Contains(c.Err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want it to contain %q", c.Err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package compose
+
+import (
+	"context"
+	"slices"
+)
+
+// Profiles returns every compose profile declared by the services in the
+// compose files, sorted.
+func Profiles(dir string, files []string) ([]string, error) {
+	specs, err := loadModel(dir, files)
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	for _, spec := range specs {
+		profiles = append(profiles, spec.profiles...)
+	}
+	slices.Sort(profiles)
+	return slices.Compact(profiles), nil
+}
+
+// ActiveProfiles returns the profiles of a project's running services,
+// sorted, or nil if no service uses a profile. Compose doesn't record which
+// profiles a project was started with, so they are inferred from the
+// services that are up.
+func ActiveProfiles(ctx context.Context, name, dir string, files []string) ([]string, error) {
+	specs, err := loadModel(dir, files)
+	if err != nil {
+		return nil, err
+	}
+	profiled := false
+	for _, spec := range specs {
+		profiled = profiled || len(spec.profiles) > 0
+	}
+	if !profiled {
+		return nil, nil
+	}
+
+	states, err := ProjectPS(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	active := []string{}
+	for _, st := range states {
+		if spec, ok := specs[st.Service]; ok && st.State == "running" {
+			active = append(active, spec.profiles...)
+		}
+	}
+	slices.Sort(active)
+	return slices.Compact(active), nil
+}
+
+// ProfileEnabled reports whether a service with the given compose profiles
+// runs when enabled are active. Services without profiles always run.
+func ProfileEnabled(serviceProfiles, enabled []string) bool {
+	if len(serviceProfiles) == 0 {
+		return true
+	}
+	for _, p := range serviceProfiles {
+		if slices.Contains(enabled, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// profileArgs returns the --profile flags enabling profiles.
+func profileArgs(profiles []string) []string {
+	var args []string
+	for _, p := range profiles {
+		args = append(args, "--profile", p)
+	}
+	return args
+}
+
+// allProfileArgs enables every profile the compose files declare, so down
+// and logs reach services whichever profiles they were started with.
+func allProfileArgs(dir string, files []string) []string {
+	profiles, _ := Profiles(dir, files)
+	return profileArgs(profiles)
+}
@@ -0,0 +1,88 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContainerState is one project container's state.
+type ContainerState struct {
+	Service  string `json:"service"`
+	Name     string `json:"name"`
+	State    string `json:"state"`  // running, exited, restarting, ...
+	Health   string `json:"health"` // healthy, unhealthy, starting, or empty without a healthcheck
+	ExitCode int    `json:"exit_code"`
+}
+
+// ProjectPS lists a compose project's containers, including stopped ones.
+func ProjectPS(ctx context.Context, name string) ([]ContainerState, error) {
+	return CurrentRuntime().ProjectPS(ctx, name)
+}
+
+// WaitReady waits until every container of a compose project is running and,
+// if it has a healthcheck, healthy. Containers that exited with code 0
+// (one-shot jobs such as migrations) count as ready, and a project with no
+// containers is ready at once. It fails early on an
+// unhealthy container or a non-zero exit, and after timeout. States are
+// re-checked whenever the project's containers emit an event, and every few
+// seconds in case the runtime can't stream events.
+func WaitReady(ctx context.Context, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rt := CurrentRuntime()
+	events := rt.Events(ctx, name)
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	var pending []string
+	for {
+		states, err := rt.ProjectPS(ctx, name)
+		if err == nil {
+			pending, err = notReady(states)
+			if err != nil {
+				return err
+			}
+			if len(pending) == 0 {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if len(pending) == 0 {
+				return fmt.Errorf("%s did not start within %s", name, timeout)
+			}
+			return fmt.Errorf("%s not ready within %s: waiting on %s", name, timeout, strings.Join(pending, ", "))
+		case _, ok := <-events:
+			if !ok {
+				events = nil // stream ended; keep polling
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// notReady returns the services that are still starting, or an error if one
+// has failed.
+func notReady(states []ContainerState) ([]string, error) {
+	var pending []string
+	for _, s := range states {
+		switch {
+		case s.State == "exited" && s.ExitCode == 0:
+			continue
+		case s.State == "exited" || s.State == "dead":
+			return nil, fmt.Errorf("service %s exited with code %d", s.Service, s.ExitCode)
+		case s.Health == "unhealthy":
+			return nil, fmt.Errorf("service %s is unhealthy", s.Service)
+		case s.State != "running" || (s.Health != "" && s.Health != "healthy"):
+			pending = append(pending, s.Service)
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
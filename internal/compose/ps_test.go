@@ -0,0 +1,116 @@
+package compose
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProjectPS(t *testing.T) {
+	tests := []struct {
+		name        string
+		containers  string            // /containers/json response
+		inspect     map[string]string // container ID -> /containers/{id}/json response
+		wantPending []string
+		wantErr     bool
+	}{
+		{
+			name: "all ready",
+			containers: `[{"Id":"w","Names":["/myapp-web-1"],"Labels":{"com.docker.compose.service":"web"}},
+				{"Id":"d","Names":["/myapp-db-1"],"Labels":{"com.docker.compose.service":"db"}},
+				{"Id":"m","Names":["/myapp-migrate-1"],"Labels":{"com.docker.compose.service":"migrate"}}]`,
+			inspect: map[string]string{
+				"w": `{"Name":"/myapp-web-1","State":{"Status":"running","Running":true},"Config":{"Labels":{"com.docker.compose.service":"web"}}}`,
+				"d": `{"Name":"/myapp-db-1","State":{"Status":"running","Running":true,"Health":{"Status":"healthy"}},"Config":{"Labels":{"com.docker.compose.service":"db"}}}`,
+				"m": `{"Name":"/myapp-migrate-1","State":{"Status":"exited","ExitCode":0},"Config":{"Labels":{"com.docker.compose.service":"migrate"}}}`,
+			},
+		},
+		{
+			name:        "health starting",
+			containers:  `[{"Id":"d","Names":["/myapp-db-1"],"Labels":{"com.docker.compose.service":"db"}}]`,
+			inspect:     map[string]string{"d": `{"Name":"/myapp-db-1","State":{"Status":"running","Running":true,"Health":{"Status":"starting"}},"Config":{"Labels":{"com.docker.compose.service":"db"}}}`},
+			wantPending: []string{"db"},
+		},
+		{
+			name:       "failed one-shot",
+			containers: `[{"Id":"m","Names":["/myapp-migrate-1"],"Labels":{"com.docker.compose.service":"migrate"}}]`,
+			inspect:    map[string]string{"m": `{"Name":"/myapp-migrate-1","State":{"Status":"exited","ExitCode":1},"Config":{"Labels":{"com.docker.compose.service":"migrate"}}}`},
+			wantErr:    true,
+		},
+		{
+			name:       "unhealthy",
+			containers: `[{"Id":"d","Names":["/myapp-db-1"],"Labels":{"com.docker.compose.service":"db"}}]`,
+			inspect:    map[string]string{"d": `{"Name":"/myapp-db-1","State":{"Status":"running","Running":true,"Health":{"Status":"unhealthy"}},"Config":{"Labels":{"com.docker.compose.service":"db"}}}`},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+				if !strings.Contains(r.URL.Query().Get("filters"), "com.docker.compose.project=myapp") {
+					t.Errorf("filters = %q, want project label", r.URL.Query().Get("filters"))
+				}
+				_, _ = w.Write([]byte(tt.containers))
+			})
+			mux.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.inspect[r.PathValue("id")]))
+			})
+			serveFakeEngine(t, mux)
+
+			states, err := ProjectPS(context.Background(), "myapp")
+			if err != nil {
+				t.Fatalf("ProjectPS: %v", err)
+			}
+			pending, err := notReady(states)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("notReady: expected error, got pending %v", pending)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("notReady: %v", err)
+			}
+			if !slices.Equal(pending, tt.wantPending) {
+				t.Errorf("pending = %v, want %v", pending, tt.wantPending)
+			}
+		})
+	}
+}
+
+// serveFakeEngine serves handler on a unix socket and points DOCKER_HOST at it.
+func serveFakeEngine(t *testing.T, handler http.Handler) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listening on fake socket: %v", err)
+	}
+	srv := &http.Server{Handler: handler}
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	t.Setenv("DOCKER_HOST", "unix://"+socket)
+}
+
+func TestWaitReadyNoContainers(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	serveFakeEngine(t, mux)
+
+	start := time.Now()
+	if err := WaitReady(context.Background(), "myapp", 10*time.Second); err != nil {
+		t.Fatalf("WaitReady: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitReady took %s for a project with no containers", elapsed)
+	}
+}
@@ -0,0 +1,385 @@
+package compose
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/docker"
+)
+
+// Runtime is a container engine devinfra can drive. Orchestration always goes
+// through the runtime's docker-compatible CLI ("<binary> compose ..."); status
+// queries go through whatever API the runtime offers.
+type Runtime interface {
+	// Name is the runtime's config value, e.g. "podman".
+	Name() string
+	// Binary is the CLI executable.
+	Binary() string
+	// Socket is the Docker-compatible API socket mounted into socket-proxy for
+	// Traefik's Docker provider, as seen by the engine (inside the VM on
+	// macOS). It is empty if the runtime doesn't provide one.
+	Socket() string
+
+	// Ping checks that the engine is reachable.
+	Ping(ctx context.Context) error
+	// RunningContainers maps compose project name to running container names.
+	RunningContainers(ctx context.Context) (map[string][]string, error)
+	// ContainerRunning reports whether the named container is running.
+	ContainerRunning(ctx context.Context, name string) bool
+	// NetworkExists reports whether the named network exists.
+	NetworkExists(ctx context.Context, name string) bool
+	// ProjectPS lists a compose project's containers, including stopped ones.
+	ProjectPS(ctx context.Context, project string) ([]ContainerState, error)
+	// Events streams container events for a compose project until ctx is
+	// done. It returns a nil channel if the runtime can't stream events.
+	Events(ctx context.Context, project string) <-chan docker.Event
+	// PortBindings lists the host ports published by running containers.
+	PortBindings(ctx context.Context) ([]PortBinding, error)
+}
+
+// PortBinding is a host port published by a running container.
+type PortBinding struct {
+	Container string
+	Project   string // compose project, if any
+	Port      int
+	Protocol  string
+}
+
+// CurrentRuntime returns the runtime selected by the runtime setting.
+func CurrentRuntime() Runtime {
+	return RuntimeFor(config.Current())
+}
+
+// RuntimeFor returns the runtime selected by s, defaulting to docker.
+func RuntimeFor(s *config.Settings) Runtime {
+	switch s.Runtime {
+	case "podman":
+		return &apiRuntime{name: "podman", socket: s.RuntimeSocket, host: podmanHost}
+	case "nerdctl":
+		return &cliRuntime{name: "nerdctl", socket: s.RuntimeSocket}
+	default:
+		r := &apiRuntime{name: "docker", socket: s.RuntimeSocket, host: dockerHost}
+		if _, ok := docker.CurrentHost(); !ok {
+			// e.g. an ssh:// context; the docker CLI reaches it for us
+			return &cliRuntime{name: "docker", socket: r.Socket()}
+		}
+		return r
+	}
+}
+
+// apiRuntime is a runtime with a Docker Engine-compatible API: docker itself
+// and podman's compat service.
+type apiRuntime struct {
+	name   string
+	socket string                  // runtime.socket override
+	host   func() (string, string) // client host URL and engine-side socket path
+}
+
+func (r *apiRuntime) Name() string   { return r.name }
+func (r *apiRuntime) Binary() string { return r.name }
+
+func (r *apiRuntime) Socket() string {
+	if r.socket != "" {
+		return r.socket
+	}
+	_, socket := r.host()
+	return socket
+}
+
+// clients holds one API client per host. Runtimes are looked up afresh for
+// each query, so sharing clients here is what lets polling loops such as
+// WaitReady reuse keep-alive connections instead of leaking a transport, and
+// its idle connections, on every tick.
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[string]*docker.Client)
+)
+
+func (r *apiRuntime) client() (*docker.Client, error) {
+	host, _ := r.host()
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if c, ok := clients[host]; ok {
+		return c, nil
+	}
+	c, err := docker.NewClientForHost(host)
+	if err != nil {
+		return nil, err
+	}
+	clients[host] = c
+	return c, nil
+}
+
+func (r *apiRuntime) Ping(ctx context.Context) error {
+	c, err := r.client()
+	if err != nil {
+		return err
+	}
+	return c.Ping(ctx)
+}
+
+func (r *apiRuntime) RunningContainers(ctx context.Context) (map[string][]string, error) {
+	c, err := r.client()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.Containers(ctx, docker.ListOptions{
+		Labels: []string{docker.LabelProject},
+		Status: []string{"running"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]string)
+	for _, ct := range containers {
+		project := ct.Labels[docker.LabelProject]
+		result[project] = append(result[project], ct.Name())
+	}
+	return result, nil
+}
+
+func (r *apiRuntime) ContainerRunning(ctx context.Context, name string) bool {
+	c, err := r.client()
+	return err == nil && c.ContainerRunning(ctx, name)
+}
+
+func (r *apiRuntime) NetworkExists(ctx context.Context, name string) bool {
+	c, err := r.client()
+	return err == nil && c.NetworkExists(ctx, name)
+}
+
+func (r *apiRuntime) ProjectPS(ctx context.Context, project string) ([]ContainerState, error) {
+	c, err := r.client()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.Containers(ctx, docker.ListOptions{
+		All:    true,
+		Labels: []string{docker.LabelProject + "=" + project},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var states []ContainerState
+	for _, ct := range containers {
+		d, err := c.InspectContainer(ctx, ct.ID)
+		if errors.Is(err, docker.ErrNotFound) {
+			continue // removed since it was listed
+		}
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, containerState(d))
+	}
+	return states, nil
+}
+
+func (r *apiRuntime) Events(ctx context.Context, project string) <-chan docker.Event {
+	c, err := r.client()
+	if err != nil {
+		return nil
+	}
+	events, _ := c.Events(ctx, []string{docker.LabelProject + "=" + project})
+	return events
+}
+
+func (r *apiRuntime) PortBindings(ctx context.Context) ([]PortBinding, error) {
+	c, err := r.client()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.Containers(ctx, docker.ListOptions{Status: []string{"running"}})
+	if err != nil {
+		return nil, err
+	}
+	var bindings []PortBinding
+	for _, ct := range containers {
+		for _, p := range ct.Ports {
+			b := PortBinding{Container: ct.Name(), Project: ct.Labels[docker.LabelProject], Port: p.PublicPort, Protocol: p.Type}
+			if p.PublicPort > 0 && !slices.Contains(bindings, b) {
+				bindings = append(bindings, b)
+			}
+		}
+	}
+	return bindings, nil
+}
+
+// dockerHost returns the host the docker CLI uses: DOCKER_HOST, the current
+// docker context's endpoint, or the local docker socket. A unix:// host
+// outside the home directory (e.g. rootless docker's) is also the socket the
+// engine mounts; one inside it is forwarded from a VM (colima, OrbStack,
+// Rancher Desktop, Docker Desktop), whose engine uses the standard socket.
+func dockerHost() (string, string) {
+	host, _ := docker.CurrentHost()
+	socket, ok := strings.CutPrefix(host, "unix://")
+	if !ok || socket == docker.LocalSocket() {
+		return host, docker.DefaultSocket
+	}
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(socket, home+string(filepath.Separator)) {
+		return host, docker.DefaultSocket
+	}
+	return host, socket
+}
+
+// podmanHost returns CONTAINER_HOST or podman's API socket. Rootless podman on
+// Linux listens under $XDG_RUNTIME_DIR; on macOS the socket lives in the
+// podman machine and is forwarded to a host path podman reports.
+func podmanHost() (string, string) {
+	const rootful = "/run/podman/podman.sock"
+	engineSocket := rootful
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && runtime.GOOS == "linux" {
+		if p := filepath.Join(dir, "podman", "podman.sock"); fileExists(p) {
+			engineSocket = p
+		}
+	}
+
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host, engineSocket
+	}
+	if runtime.GOOS == "darwin" {
+		out, err := exec.Command("podman", "machine", "inspect", "--format", "{{.ConnectionInfo.PodmanSocket.Path}}").Output()
+		if p := strings.TrimSpace(string(out)); err == nil && p != "" {
+			return "unix://" + p, engineSocket
+		}
+	}
+	return "unix://" + engineSocket, engineSocket
+}
+
+// cliRuntime answers status queries through a runtime's CLI, whose inspect
+// output is docker-compatible. It drives containerd through nerdctl, which
+// has no API server, and docker when its host is one the API client can't
+// reach, such as an ssh:// context.
+type cliRuntime struct {
+	name   string
+	socket string // runtime.socket override, or docker's engine socket
+}
+
+func (r *cliRuntime) Name() string   { return r.name }
+func (r *cliRuntime) Binary() string { return r.name }
+func (r *cliRuntime) Socket() string { return r.socket }
+
+func (r *cliRuntime) Ping(ctx context.Context) error {
+	if out, err := exec.CommandContext(ctx, r.name, "info").CombinedOutput(); err != nil {
+		return fmt.Errorf("%s info: %w: %s", r.name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// inspect lists containers matching the ps filter args and inspects them.
+func (r *cliRuntime) inspect(ctx context.Context, psArgs ...string) ([]docker.ContainerDetails, error) {
+	args := append([]string{"ps", "-q"}, psArgs...)
+	out, err := exec.CommandContext(ctx, r.name, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s ps: %w", r.name, err)
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	out, err = exec.CommandContext(ctx, r.name, append([]string{"container", "inspect"}, ids...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s inspect: %w", r.name, err)
+	}
+	var details []docker.ContainerDetails
+	if err := json.Unmarshal(out, &details); err != nil {
+		return nil, fmt.Errorf("parsing %s inspect: %w", r.name, err)
+	}
+	return details, nil
+}
+
+func (r *cliRuntime) RunningContainers(ctx context.Context) (map[string][]string, error) {
+	details, err := r.inspect(ctx, "--filter", "label="+docker.LabelProject)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]string)
+	for _, d := range details {
+		project := d.Config.Labels[docker.LabelProject]
+		result[project] = append(result[project], strings.TrimPrefix(d.Name, "/"))
+	}
+	return result, nil
+}
+
+func (r *cliRuntime) ContainerRunning(ctx context.Context, name string) bool {
+	out, err := exec.CommandContext(ctx, r.name, "container", "inspect", name).Output()
+	if err != nil {
+		return false
+	}
+	var details []docker.ContainerDetails
+	return json.Unmarshal(out, &details) == nil && len(details) == 1 && details[0].State.Running
+}
+
+func (r *cliRuntime) NetworkExists(ctx context.Context, name string) bool {
+	return exec.CommandContext(ctx, r.name, "network", "inspect", name).Run() == nil
+}
+
+func (r *cliRuntime) ProjectPS(ctx context.Context, project string) ([]ContainerState, error) {
+	details, err := r.inspect(ctx, "-a", "--filter", "label="+docker.LabelProject+"="+project)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]ContainerState, len(details))
+	for i := range details {
+		states[i] = containerState(&details[i])
+	}
+	return states, nil
+}
+
+func (r *cliRuntime) PortBindings(ctx context.Context) ([]PortBinding, error) {
+	details, err := r.inspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var bindings []PortBinding
+	for _, d := range details {
+		for spec, hosts := range d.NetworkSettings.Ports {
+			_, proto, _ := strings.Cut(spec, "/")
+			for _, h := range hosts {
+				port, err := strconv.Atoi(h.HostPort)
+				if err != nil || port == 0 {
+					continue
+				}
+				b := PortBinding{Container: strings.TrimPrefix(d.Name, "/"), Project: d.Config.Labels[docker.LabelProject], Port: port, Protocol: cmp.Or(proto, "tcp")}
+				if !slices.Contains(bindings, b) {
+					bindings = append(bindings, b)
+				}
+			}
+		}
+	}
+	return bindings, nil
+}
+
+func (r *cliRuntime) Events(ctx context.Context, project string) <-chan docker.Event {
+	return nil
+}
+
+// containerState converts inspect output to a ContainerState.
+func containerState(d *docker.ContainerDetails) ContainerState {
+	return ContainerState{
+		Service:  d.Config.Labels[docker.LabelService],
+		Name:     strings.TrimPrefix(d.Name, "/"),
+		State:    d.State.Status,
+		Health:   d.HealthStatus(),
+		ExitCode: d.State.ExitCode,
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
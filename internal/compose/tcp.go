@@ -0,0 +1,26 @@
+package compose
+
+// TCPEntrypoint is a Traefik entrypoint for a TCP protocol. Routers on it
+// match clients by the TLS SNI host name, so one port serves every project.
+type TCPEntrypoint struct {
+	Name string // also the protocol, e.g. "postgres"
+	Port int    // published on the host and listened on by Traefik
+}
+
+// TCPEntrypoints are the TCP entrypoints the core infrastructure opens when
+// tcp.routing is enabled, one per protocol of the flavors.
+var TCPEntrypoints = []TCPEntrypoint{
+	{Name: "postgres", Port: 5432},
+	{Name: "amqp", Port: 5672},
+	{Name: "redis", Port: 6379},
+}
+
+// LookupTCPEntrypoint returns the TCP entrypoint with the given name.
+func LookupTCPEntrypoint(name string) (TCPEntrypoint, bool) {
+	for _, e := range TCPEntrypoints {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return TCPEntrypoint{}, false
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CycleError reports a dependency cycle between projects.
+type CycleError struct {
+	Path []string // e.g. [a b c a]
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(e.Path, " → "))
+}
+
+// CheckCycles returns a *CycleError if the projects' depends_on form a cycle.
+// Dependencies on unregistered projects are ignored here; StartOrder reports
+// them when they are actually needed.
+func (r *Registry) CheckCycles() error {
+	state := make(map[string]int) // 0 unvisited, 1 visiting, 2 done
+	var stack []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			start := slices.Index(stack, name)
+			return &CycleError{Path: append(slices.Clone(stack[start:]), name)}
+		case 2:
+			return nil
+		}
+		p := r.Get(name)
+		if p == nil {
+			return nil
+		}
+		state[name] = 1
+		stack = append(stack, name)
+		for _, dep := range p.DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = 2
+		return nil
+	}
+
+	for _, p := range r.Projects {
+		if err := visit(p.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartOrder returns the named projects together with everything they depend
+// on, transitively, ordered so that each project comes after its
+// dependencies. Projects without a dependency between them keep registry
+// order.
+func (r *Registry) StartOrder(names []string) ([]Project, error) {
+	if err := r.CheckCycles(); err != nil {
+		return nil, err
+	}
+
+	// Collect the closure of the requested projects.
+	need := make(map[string]bool)
+	var collect func(name, from string) error
+	collect = func(name, from string) error {
+		if need[name] {
+			return nil
+		}
+		p := r.Get(name)
+		if p == nil {
+			if from != "" {
+				return fmt.Errorf("project %q depends on %q, which is not registered", from, name)
+			}
+			return fmt.Errorf("project %q not found in registry", name)
+		}
+		need[name] = true
+		for _, dep := range p.DependsOn {
+			if err := collect(dep, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range names {
+		if err := collect(name, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// Depth-first over registry order emits dependencies before dependents.
+	done := make(map[string]bool)
+	var order []Project
+	var emit func(p Project)
+	emit = func(p Project) {
+		if done[p.Name] {
+			return
+		}
+		done[p.Name] = true
+		for _, dep := range p.DependsOn {
+			emit(*r.Get(dep))
+		}
+		order = append(order, p)
+	}
+	for _, p := range r.Projects {
+		if need[p.Name] {
+			emit(p)
+		}
+	}
+	return order, nil
+}
+
+// Dependents returns the names of projects that depend on name, directly or
+// transitively, in registry order.
+func (r *Registry) Dependents(name string) []string {
+	dependent := map[string]bool{name: true}
+	for changed := true; changed; {
+		changed = false
+		for _, p := range r.Projects {
+			if dependent[p.Name] {
+				continue
+			}
+			if slices.ContainsFunc(p.DependsOn, func(d string) bool { return dependent[d] }) {
+				dependent[p.Name] = true
+				changed = true
+			}
+		}
+	}
+
+	var out []string
+	for _, p := range r.Projects {
+		if p.Name != name && dependent[p.Name] {
+			out = append(out, p.Name)
+		}
+	}
+	return out
+}
+
+// RenameDependency points the depends_on entries naming oldName at newName
+// and returns the projects it changed, in registry order.
+func (r *Registry) RenameDependency(oldName, newName string) []Project {
+	var changed []Project
+	for i := range r.Projects {
+		p := &r.Projects[i]
+		if j := slices.Index(p.DependsOn, oldName); j >= 0 {
+			p.DependsOn[j] = newName
+			changed = append(changed, *p)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestStartOrder(t *testing.T) {
+	reg := &Registry{Projects: []Project{
+		{Name: "shop", DependsOn: []string{"api", "auth"}},
+		{Name: "api", DependsOn: []string{"auth"}},
+		{Name: "blog"},
+		{Name: "auth"},
+	}}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "dependencies first",
+			names: []string{"shop"},
+			want:  []string{"auth", "api", "shop"},
+		},
+		{
+			name:  "whole registry",
+			names: reg.List(),
+			want:  []string{"auth", "api", "shop", "blog"},
+		},
+		{
+			name:  "no dependencies",
+			names: []string{"blog"},
+			want:  []string{"blog"},
+		},
+		{
+			name:    "unknown project",
+			names:   []string{"nope"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := reg.StartOrder(tt.names)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("StartOrder: expected error, got %v", order)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StartOrder: %v", err)
+			}
+			var got []string
+			for _, p := range order {
+				got = append(got, p.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("StartOrder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := reg.Dependents("auth"); !slices.Equal(got, []string{"shop", "api"}) {
+		t.Errorf("Dependents(auth) = %v, want [shop api]", got)
+	}
+}
+
+func TestRegistryAddRejectsCycle(t *testing.T) {
+	reg := &Registry{Projects: []Project{
+		{Name: "api", DependsOn: []string{"auth"}},
+		{Name: "auth", DependsOn: []string{"gateway"}},
+	}}
+
+	err := reg.Add(Project{Name: "gateway", DependsOn: []string{"api"}})
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Add error = %v, want *CycleError", err)
+	}
+	if reg.Get("gateway") != nil {
+		t.Errorf("project with a cycle was registered")
+	}
+}
+
+func TestRenameDependency(t *testing.T) {
+	reg := &Registry{Projects: []Project{
+		{Name: "shop", DependsOn: []string{"api", "auth"}},
+		{Name: "api", DependsOn: []string{"auth"}},
+		{Name: "blog"},
+		{Name: "login"}, // auth, already renamed
+	}}
+
+	changed := reg.RenameDependency("auth", "login")
+	var names []string
+	for _, p := range changed {
+		names = append(names, p.Name)
+	}
+	if want := []string{"shop", "api"}; !slices.Equal(names, want) {
+		t.Errorf("changed = %v, want %v", names, want)
+	}
+	if got := reg.Get("shop").DependsOn; !slices.Equal(got, []string{"api", "login"}) {
+		t.Errorf("shop depends on %v, want [api login]", got)
+	}
+	if _, err := reg.StartOrder([]string{"shop"}); err != nil {
+		t.Errorf("StartOrder after rename: %v", err)
+	}
+}
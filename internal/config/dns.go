@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DefaultDNSUpstream is used for lookups outside the local TLD when no
+// upstream servers are configured.
+const DefaultDNSUpstream = "8.8.8.8,8.8.4.4"
+
+// DNSForward sends lookups for Domain (and its subdomains) to Server instead
+// of the upstream servers, e.g. an internal zone behind a VPN resolver.
+type DNSForward struct {
+	Domain string `json:"domain"`
+	Server string `json:"server"`
+}
+
+// ParseDNSServers parses a comma-separated list of DNS servers. Each server is
+// an IP address with an optional dnsmasq-style "#port" suffix.
+func ParseDNSServers(s string) ([]string, error) {
+	var servers []string
+	for _, part := range splitList(s) {
+		if err := validateDNSServer(part); err != nil {
+			return nil, err
+		}
+		servers = append(servers, part)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("at least one upstream DNS server is required")
+	}
+	return servers, nil
+}
+
+// ParseDNSForwards parses a comma-separated list of domain=server forwarding
+// rules, e.g. "corp.internal=10.0.0.2,vpn.lan=10.0.0.3#5353".
+func ParseDNSForwards(s string) ([]DNSForward, error) {
+	var rules []DNSForward
+	for _, part := range splitList(s) {
+		domain, server, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("forwarding rule %q is invalid: must be domain=server (e.g. corp.internal=10.0.0.2)", part)
+		}
+		domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
+		server = strings.TrimSpace(server)
+		if !domainRe.MatchString(domain) && !tldLabelRe.MatchString(domain) {
+			return nil, fmt.Errorf("forwarding rule %q is invalid: %q is not a valid domain", part, domain)
+		}
+		if err := validateDNSServer(server); err != nil {
+			return nil, fmt.Errorf("forwarding rule %q is invalid: %w", part, err)
+		}
+		rules = append(rules, DNSForward{Domain: domain, Server: server})
+	}
+	return rules, nil
+}
+
+func validateDNSServer(s string) error {
+	host, port, hasPort := strings.Cut(s, "#")
+	if net.ParseIP(host) == nil {
+		return fmt.Errorf("DNS server %q is invalid: must be an IP address, optionally followed by #port", s)
+	}
+	if hasPort {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("DNS server %q is invalid: port must be between 1 and 65535", s)
+		}
+	}
+	return nil
+}
+
+func validateDNSUpstream(s string) error {
+	_, err := ParseDNSServers(s)
+	return err
+}
+
+func validateDNSForward(s string) error {
+	_, err := ParseDNSForwards(s)
+	return err
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDNSForwards(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []DNSForward
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{
+			in: "corp.internal=10.0.0.2, vpn.lan.=10.8.0.1#5353",
+			want: []DNSForward{
+				{Domain: "corp.internal", Server: "10.0.0.2"},
+				{Domain: "vpn.lan", Server: "10.8.0.1#5353"},
+			},
+		},
+		{in: "lan=192.168.1.1", want: []DNSForward{{Domain: "lan", Server: "192.168.1.1"}}},
+		{in: "corp.internal", wantErr: true},
+		{in: "corp.internal=resolver.corp", wantErr: true},
+		{in: "corp.internal=10.0.0.2#99999", wantErr: true},
+		{in: "bad_domain=10.0.0.2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDNSForwards(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDNSForwards(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseDNSForwards(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDNSServers(t *testing.T) {
+	got, err := ParseDNSServers("1.1.1.1, 2606:4700:4700::1111,9.9.9.9#53")
+	if err != nil {
+		t.Fatalf("ParseDNSServers: %v", err)
+	}
+	want := []string{"1.1.1.1", "2606:4700:4700::1111", "9.9.9.9#53"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseDNSServers = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"", " , ", "dns.google"} {
+		if _, err := ParseDNSServers(bad); err == nil {
+			t.Errorf("ParseDNSServers(%q): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value sources reported by Key.Resolve, in precedence order.
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceFile    = ".env"
+	SourceDefault = "default"
+	SourceUnset   = "unset"
+)
+
+// Key describes a user-facing configuration key backed by a variable in the
+// environment or the config directory's .env file.
+type Key struct {
+	Name        string // dotted name used on the command line, e.g. "remote.domain"
+	Env         string // environment / .env variable, e.g. "REMOTE_DOMAIN"
+	Default     string
+	Description string
+	Secret      bool // redacted unless explicitly requested
+	Validate    func(string) error
+}
+
+// Keys lists every supported configuration key in display order.
+var Keys = []Key{
+	{
+		Name:        "tld",
+		Env:         "TLD",
+		Default:     "test",
+		Description: "Local TLD (e.g. claw, test)",
+		Validate:    validateTLDLabel,
+	},
+	{
+		Name:        "runtime",
+		Env:         "DEVINFRA_RUNTIME",
+		Default:     "docker",
+		Description: "Container runtime: docker, podman, or nerdctl",
+		Validate:    validateRuntime,
+	},
+	{
+		Name:        "runtime.socket",
+		Env:         "DEVINFRA_RUNTIME_SOCKET",
+		Description: "Docker-compatible API socket Traefik reads routes from (default depends on runtime)",
+		Validate:    validateSocketPath,
+	},
+	{
+		Name:        "dns.port",
+		Env:         "DNS_PORT",
+		Default:     "5354",
+		Description: "Host port dnsmasq listens on",
+		Validate:    validateDNSPort,
+	},
+	{
+		Name:        "dns.upstream",
+		Env:         "DNS_UPSTREAM",
+		Default:     DefaultDNSUpstream,
+		Description: "Comma-separated upstream DNS servers (e.g. 1.1.1.1,9.9.9.9)",
+		Validate:    validateDNSUpstream,
+	},
+	{
+		Name:        "dns.forward",
+		Env:         "DNS_FORWARD",
+		Description: "Comma-separated domain=server forwarding rules (e.g. corp.internal=10.0.0.2)",
+		Validate:    validateDNSForward,
+	},
+	{
+		Name:        "tcp.routing",
+		Env:         "TCP_ROUTING",
+		Default:     "false",
+		Description: "Route flavor databases and brokers by TLS SNI on Traefik TCP ports 5432, 5672, and 6379 (true/false)",
+		Validate:    validateBool,
+	},
+	{
+		Name:        "remote.enabled",
+		Env:         "REMOTE_ENABLED",
+		Default:     "false",
+		Description: "Enable cross-device remote domain (true/false)",
+		Validate:    validateBool,
+	},
+	{
+		Name:        "remote.domain",
+		Env:         "REMOTE_DOMAIN",
+		Description: "Remote base domain (e.g. claw.sarvent.cloud)",
+		Validate:    ValidateRemoteDomain,
+	},
+	{
+		Name:        "remote.dns_provider",
+		Env:         "REMOTE_DNS_PROVIDER",
+		Description: "DNS provider for ACME challenge (cloudflare)",
+	},
+	{
+		Name:        "remote.acme_email",
+		Env:         "REMOTE_ACME_EMAIL",
+		Description: "Email for Let's Encrypt certificate notifications",
+		Validate:    ValidateACMEEmail,
+	},
+	{
+		Name:        "remote.cloudflare_zone_token",
+		Env:         "CF_DNS_API_TOKEN",
+		Description: "Cloudflare API token with Zone:DNS:Edit permission",
+		Secret:      true,
+	},
+}
+
+// LookupKey returns the configuration key with the given name.
+func LookupKey(name string) (Key, error) {
+	for _, k := range Keys {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+	return Key{}, fmt.Errorf("unsupported config key %q; run 'di config list' for supported keys", name)
+}
+
+// KeyNames returns the names of all supported keys, sorted.
+func KeyNames() []string {
+	names := make([]string, len(Keys))
+	for i, k := range Keys {
+		names[i] = k.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Value is a resolved configuration value and where it came from.
+type Value struct {
+	Key    string `json:"key"`
+	Env    string `json:"env"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"secret,omitempty"`
+	Error  string `json:"error,omitempty"` // set when a configured value fails validation
+}
+
+// Resolve looks up the key's current value, ignoring command-line flags.
+// Environment variables take precedence over the .env file, which takes
+// precedence over the default.
+func (k Key) Resolve() Value {
+	return k.resolve(readEnvFile(), nil)
+}
+
+func (k Key) resolve(env, flags map[string]string) Value {
+	v := Value{Key: k.Name, Env: k.Env, Secret: k.Secret}
+	switch {
+	case flags[k.Name] != "":
+		v.Value, v.Source = flags[k.Name], SourceFlag
+	case os.Getenv(k.Env) != "":
+		v.Value, v.Source = os.Getenv(k.Env), SourceEnv
+	case env[k.Env] != "":
+		v.Value, v.Source = env[k.Env], SourceFile
+	case k.Default != "":
+		v.Value, v.Source = k.Default, SourceDefault
+	default:
+		v.Source = SourceUnset
+	}
+	if k.Validate != nil && v.Source != SourceDefault && v.Source != SourceUnset {
+		if err := k.Validate(v.Value); err != nil {
+			v.Error = err.Error()
+		}
+	}
+	return v
+}
+
+// ResolveAll resolves every supported key, reading .env only once.
+func ResolveAll() []Value {
+	env := readEnvFile()
+	values := make([]Value, len(Keys))
+	for i, k := range Keys {
+		values[i] = k.resolve(env, nil)
+	}
+	return values
+}
+
+// Redacted returns a copy of v with a secret value masked, keeping the last
+// four characters of long values so different tokens can be told apart.
+func (v Value) Redacted() Value {
+	if !v.Secret || v.Value == "" {
+		return v
+	}
+	if len(v.Value) >= 12 {
+		v.Value = "****" + v.Value[len(v.Value)-4:]
+	} else {
+		v.Value = "****"
+	}
+	return v
+}
+
+func validateBool(s string) error {
+	if s != "true" && s != "false" {
+		return fmt.Errorf("value must be 'true' or 'false'")
+	}
+	return nil
+}
+
+func validateDNSPort(s string) error {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("DNS port %q is invalid: must be a number between 1 and 65535", s)
+	}
+	if slices.Contains(traefikPorts, port) {
+		return fmt.Errorf("DNS port %d is used by Traefik", port)
+	}
+	return nil
+}
+
+// Runtimes are the supported container runtimes. Each provides a
+// docker-compatible CLI with a compose subcommand.
+var Runtimes = []string{"docker", "podman", "nerdctl"}
+
+func validateRuntime(s string) error {
+	if !slices.Contains(Runtimes, s) {
+		return fmt.Errorf("runtime %q is not supported: must be one of %s", s, strings.Join(Runtimes, ", "))
+	}
+	return nil
+}
+
+func validateSocketPath(s string) error {
+	if !filepath.IsAbs(s) {
+		return fmt.Errorf("socket %q must be an absolute path", s)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestKeyResolve(t *testing.T) {
+	t.Setenv("DEVINFRA_HOME", t.TempDir())
+	t.Setenv("TLD", "")
+	t.Setenv("REMOTE_DOMAIN", "")
+	t.Setenv("REMOTE_ACME_EMAIL", "from-env@example.com")
+	env := "TLD=claw\nREMOTE_DOMAIN=bad_domain\nREMOTE_ACME_EMAIL=file@example.com\n"
+	if err := os.WriteFile(EnvFilePath(), []byte(env), 0600); err != nil {
+		t.Fatalf("writing .env: %v", err)
+	}
+
+	tests := []struct {
+		key       string
+		want      string
+		source    string
+		wantError bool
+	}{
+		{key: "tld", want: "claw", source: SourceFile},
+		{key: "dns.port", want: "5354", source: SourceDefault},
+		{key: "remote.acme_email", want: "from-env@example.com", source: SourceEnv},
+		{key: "remote.domain", want: "bad_domain", source: SourceFile, wantError: true},
+		{key: "remote.dns_provider", want: "", source: SourceUnset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			k, err := LookupKey(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := k.Resolve()
+			if v.Value != tt.want || v.Source != tt.source {
+				t.Errorf("Resolve() = %q from %s, want %q from %s", v.Value, v.Source, tt.want, tt.source)
+			}
+			if (v.Error != "") != tt.wantError {
+				t.Errorf("Resolve().Error = %q, wantError %v", v.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestValueRedacted(t *testing.T) {
+	tests := []struct {
+		v    Value
+		want string
+	}{
+		{Value{Value: "abcdefghijklmnop", Secret: true}, "****mnop"},
+		{Value{Value: "short", Secret: true}, "****"},
+		{Value{Value: "", Secret: true}, ""},
+		{Value{Value: "plain"}, "plain"},
+	}
+	for _, tt := range tests {
+		if got := tt.v.Redacted().Value; got != tt.want {
+			t.Errorf("Redacted(%q) = %q, want %q", tt.v.Value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// LockTimeout is how long AcquireLock waits for another di process to release
+// the config directory lock before giving up.
+var LockTimeout = 10 * time.Second
+
+// lockPollInterval is how often AcquireLock retries while the lock is held.
+const lockPollInterval = 100 * time.Millisecond
+
+// LockError is returned when the config directory lock could not be acquired
+// before LockTimeout elapsed.
+type LockError struct {
+	Path  string
+	PID   int  // pid recorded by the holder; 0 if unknown
+	Stale bool // true if the recorded pid is no longer running
+}
+
+func (e *LockError) Error() string {
+	switch {
+	case e.PID == 0:
+		return fmt.Sprintf("another di is running (lock %s is held)", e.Path)
+	case e.Stale:
+		return fmt.Sprintf("lock %s is held but its recorded owner (pid %d) is no longer running; if no other di is running, remove the lock file and retry", e.Path, e.PID)
+	default:
+		return fmt.Sprintf("another di is running (pid %d); wait for it to finish and retry", e.PID)
+	}
+}
+
+// Lock is an advisory, cross-process lock on the config directory.
+// It guards read-modify-write cycles of projects.yaml and .env.
+type Lock struct {
+	f *os.File
+}
+
+// AcquireLock takes an exclusive flock on the config directory lock file,
+// waiting up to LockTimeout. The holder's pid is written into the file so a
+// blocked process can report who owns it. The kernel drops the lock when the
+// holder exits, so a crashed di never leaves the directory locked; a pid in the
+// file that is no longer running is only reported as stale for diagnostics.
+func AcquireLock() (*Lock, error) {
+	if err := os.MkdirAll(ConfigDir(), 0700); err != nil {
+		return nil, fmt.Errorf("creating config directory: %w", err)
+	}
+
+	path := LockPath()
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			_ = f.Close()
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			pid := readLockPID(path)
+			return nil, &LockError{Path: path, PID: pid, Stale: pid != 0 && !processAlive(pid)}
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	// Record our pid for diagnostics; failure here doesn't affect the lock itself.
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	return &Lock{f: f}, nil
+}
+
+// Release clears the recorded pid and drops the lock.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	_ = l.f.Truncate(0)
+	err := syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// WithLock runs fn while holding the config directory lock.
+func WithLock(fn func() error) error {
+	lock, err := AcquireLock()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
+	return fn()
+}
+
+// readLockPID returns the pid recorded in the lock file, or 0 if none.
+func readLockPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
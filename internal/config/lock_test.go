@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	t.Setenv("DEVINFRA_HOME", t.TempDir())
+
+	orig := LockTimeout
+	LockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { LockTimeout = orig })
+
+	first, err := AcquireLock()
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+
+	_, err = AcquireLock()
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("second AcquireLock error = %v, want *LockError", err)
+	}
+	if lockErr.PID != os.Getpid() {
+		t.Errorf("LockError.PID = %d, want %d", lockErr.PID, os.Getpid())
+	}
+	if lockErr.Stale {
+		t.Errorf("LockError.Stale = true for a live holder")
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	second, err := AcquireLock()
+	if err != nil {
+		t.Fatalf("AcquireLock after release: %v", err)
+	}
+	_ = second.Release()
+}
+
+func TestUpdateRegistry(t *testing.T) {
+	t.Setenv("DEVINFRA_HOME", t.TempDir())
+
+	for _, name := range []string{"alpha", "beta"} {
+		if err := UpdateRegistry(func(reg *Registry) error {
+			return reg.Add(Project{Name: name})
+		}); err != nil {
+			t.Fatalf("UpdateRegistry(add %s): %v", name, err)
+		}
+	}
+
+	// An error from fn must leave the registry untouched.
+	wantErr := errors.New("boom")
+	if err := UpdateRegistry(func(reg *Registry) error {
+		_ = reg.Remove("alpha")
+		return wantErr
+	}); !errors.Is(err, wantErr) {
+		t.Fatalf("UpdateRegistry error = %v, want %v", err, wantErr)
+	}
+
+	reg, err := LoadRegistry()
+	if err != nil {
+		t.Fatalf("LoadRegistry: %v", err)
+	}
+	got := reg.List()
+	if len(got) != 2 || got[0] != "alpha" || got[1] != "beta" {
+		t.Errorf("projects = %v, want [alpha beta]", got)
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFileName is the name of the committed, per-project manifest that
+// describes how devinfra should register a project.
+const ManifestFileName = ".devinfra.yaml"
+
+// Manifest is the in-repo project definition read from .devinfra.yaml. It lets
+// a team share one project definition instead of each developer re-creating it
+// with the add wizard. Apart from host_mode, which defaults to docker mode,
+// fields left out of the manifest keep whatever the registry already has.
+type Manifest struct {
+	Name         string    `yaml:"name,omitempty"`
+	HostMode     bool      `yaml:"host_mode,omitempty"`
+	ComposeFiles []string  `yaml:"compose_files,omitempty"`
+	Services     []Service `yaml:"services,omitempty"`
+	Flavors      []string  `yaml:"flavors,omitempty"`
+	Domains      []string  `yaml:"domains,omitempty"`
+	DependsOn    []string  `yaml:"depends_on,omitempty"`
+	Profiles     []string  `yaml:"profiles,omitempty"`
+}
+
+// ManifestPath returns the manifest path for a project directory.
+func ManifestPath(dir string) string {
+	return filepath.Join(dir, ManifestFileName)
+}
+
+// LoadManifest reads and validates the manifest in dir.
+// Returns (nil, nil) if the directory has no manifest.
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(ManifestPath(dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", ManifestFileName, err)
+	}
+
+	var m Manifest
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", ManifestFileName, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestFileName, err)
+	}
+	return &m, nil
+}
+
+// Validate checks the manifest's name, services and their commands,
+// dependencies, profiles, domains, and compose file paths.
+// Flavor names are checked by the caller against the available templates.
+func (m *Manifest) Validate() error {
+	if m.Name != "" {
+		if err := ValidateName(m.Name); err != nil {
+			return fmt.Errorf("name: %w", err)
+		}
+	}
+
+	if err := ValidateServices(m.Services); err != nil {
+		return err
+	}
+	for _, svc := range m.Services {
+		if svc.Command != "" && !m.HostMode && !svc.Host {
+			return fmt.Errorf("service %q: command only applies to host services", svc.Name)
+		}
+	}
+
+	for _, dep := range m.DependsOn {
+		if err := ValidateName(dep); err != nil {
+			return fmt.Errorf("depends_on %q: %w", dep, err)
+		}
+		if dep == m.Name {
+			return fmt.Errorf("depends_on: project cannot depend on itself")
+		}
+	}
+
+	for _, pr := range m.Profiles {
+		if err := ValidateProfile(pr); err != nil {
+			return fmt.Errorf("profiles: %w", err)
+		}
+	}
+
+	for _, d := range m.Domains {
+		if err := ValidateExtraDomain(d); err != nil {
+			return fmt.Errorf("domains: %w", err)
+		}
+	}
+
+	for _, f := range m.ComposeFiles {
+		if f == "" || filepath.IsAbs(f) || strings.HasPrefix(filepath.Clean(f), "..") {
+			return fmt.Errorf("compose file %q must be a path relative to the project directory", f)
+		}
+	}
+	return nil
+}
+
+// ApplyTo overwrites the fields of p that the manifest declares.
+func (m *Manifest) ApplyTo(p *Project) {
+	p.HostMode = m.HostMode
+	if len(m.ComposeFiles) > 0 {
+		p.ComposeFile = m.ComposeFiles[0]
+		p.ComposeOverrides = append([]string(nil), m.ComposeFiles[1:]...)
+	}
+	if m.Services != nil {
+		p.Services = append([]Service(nil), m.Services...)
+	}
+	if m.Flavors != nil {
+		p.Flavors = append([]string(nil), m.Flavors...)
+	}
+	if m.Domains != nil {
+		p.ExtraDomains = append([]string(nil), m.Domains...)
+	}
+	if m.DependsOn != nil {
+		p.DependsOn = append([]string(nil), m.DependsOn...)
+	}
+	if m.Profiles != nil {
+		p.Profiles = append([]string(nil), m.Profiles...)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // empty means no manifest file
+		wantNil bool
+		wantErr bool
+	}{
+		{
+			name:    "no manifest",
+			wantNil: true,
+		},
+		{
+			name: "valid manifest",
+			content: `
+name: myapp
+compose_files:
+  - docker-compose.yaml
+  - docker-compose.override.yaml
+services:
+  - name: web
+    port: 3000
+    subdomain: "@"
+  - name: worker
+    port: 4000
+    host: true
+domains:
+  - "*.myapp.example.dev"
+flavors:
+  - postgres
+depends_on:
+  - auth
+`,
+		},
+		{
+			name:    "unknown field",
+			content: "name: myapp\nservice:\n  - name: web\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid service name",
+			content: "services:\n  - name: Web\n    port: 3000\n",
+			wantErr: true,
+		},
+		{
+			name:    "reserved port",
+			content: "services:\n  - name: web\n    port: 443\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid subdomain",
+			content: "services:\n  - name: web\n    port: 3000\n    subdomain: api.v2\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate subdomain",
+			content: "services:\n  - name: web\n    port: 3000\n    subdomain: api\n  - name: api\n    port: 4000\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid domain",
+			content: "domains:\n  - '*.bad_domain'\n",
+			wantErr: true,
+		},
+		{
+			name:    "self dependency",
+			content: "name: myapp\ndepends_on:\n  - myapp\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid profile",
+			content: "profiles:\n  - -workers\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown service routing",
+			content: "services:\n  - name: web\n    port: 3000\n    routing: replace\n",
+			wantErr: true,
+		},
+		{
+			name:    "host service command",
+			content: "services:\n  - name: web\n    port: 3000\n    host: true\n    command: npm run dev\n    workdir: frontend\n",
+		},
+		{
+			name:    "command on docker service",
+			content: "services:\n  - name: web\n    port: 3000\n    command: npm run dev\n",
+			wantErr: true,
+		},
+		{
+			name:    "workdir outside project",
+			content: "host_mode: true\nservices:\n  - name: web\n    port: 3000\n    command: npm run dev\n    workdir: ../web\n",
+			wantErr: true,
+		},
+		{
+			name:    "compose file outside project",
+			content: "compose_files:\n  - ../other/docker-compose.yaml\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(dir, ManifestFileName), []byte(tt.content), 0644); err != nil {
+					t.Fatalf("writing manifest: %v", err)
+				}
+			}
+
+			m, err := LoadManifest(dir)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LoadManifest: expected error, got %+v", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadManifest: %v", err)
+			}
+			if (m == nil) != tt.wantNil {
+				t.Fatalf("LoadManifest = %+v, wantNil %v", m, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestManifestApplyTo(t *testing.T) {
+	m := Manifest{
+		ComposeFiles: []string{"compose.yaml", "compose.override.yaml"},
+		Services:     []Service{{Name: "web", Port: 3000}},
+	}
+	p := Project{
+		Name:     "myapp",
+		HostMode: true,
+		Services: []Service{{Name: "old", Port: 8080}},
+		Flavors:  []string{"redis"},
+	}
+
+	m.ApplyTo(&p)
+
+	if p.HostMode {
+		t.Errorf("HostMode = true, want false")
+	}
+	if p.ComposeFile != "compose.yaml" || !slices.Equal(p.ComposeOverrides, []string{"compose.override.yaml"}) {
+		t.Errorf("compose files = %q + %q", p.ComposeFile, p.ComposeOverrides)
+	}
+	if !slices.Equal(p.Services, m.Services) {
+		t.Errorf("Services = %v, want %v", p.Services, m.Services)
+	}
+	// Flavors were not declared by the manifest, so they are left alone.
+	if !slices.Equal(p.Flavors, []string{"redis"}) {
+		t.Errorf("Flavors = %v, want [redis]", p.Flavors)
+	}
+}
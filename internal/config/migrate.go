@@ -0,0 +1,234 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentSchemaVersion is the projects.yaml schema version written by this
+// binary. Bump it and append to registryMigrations whenever the registry
+// format changes.
+const CurrentSchemaVersion = 8
+
+// registryMigration upgrades a raw registry document from version-1 to version.
+type registryMigration struct {
+	version     int
+	description string
+	apply       func(doc map[string]any) error
+}
+
+// registryMigrations are applied in order to bring an older registry up to
+// CurrentSchemaVersion. Migrations operate on the raw YAML document so they can
+// rename or reshape fields that the current structs no longer know about.
+var registryMigrations = []registryMigration{
+	{
+		version:     1,
+		description: "stamp schema_version on unversioned registry",
+		apply:       func(doc map[string]any) error { return nil },
+	},
+	{
+		// Older binaries would silently drop compose_overrides on save.
+		version:     2,
+		description: "add compose_overrides for manifest-declared compose files",
+		apply:       func(doc map[string]any) error { return nil },
+	},
+	{
+		version:     3,
+		description: "add extra_domains and per-service subdomain/host",
+		apply:       func(doc map[string]any) error { return nil },
+	},
+	{
+		version:     4,
+		description: "add project tags",
+		apply:       func(doc map[string]any) error { return nil },
+	},
+	{
+		version:     5,
+		description: "add depends_on between projects",
+		apply:       func(doc map[string]any) error { return nil },
+	},
+	{
+		version:     6,
+		description: "add per-service routing for existing Traefik labels",
+		apply:       func(doc map[string]any) error { return nil },
+	},
+	{
+		version:     7,
+		description: "add default compose profiles",
+		apply:       func(doc map[string]any) error { return nil },
+	},
+	{
+		version:     8,
+		description: "add commands and working directories for host services",
+		apply:       func(doc map[string]any) error { return nil },
+	},
+}
+
+// MigrationStep describes a single migration applied to the registry.
+type MigrationStep struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+}
+
+// MigrationPlan describes how the on-disk registry would be upgraded.
+type MigrationPlan struct {
+	From   int             `json:"from"`
+	To     int             `json:"to"`
+	Steps  []MigrationStep `json:"steps"`
+	Before []byte          `json:"-"` // original file contents
+	After  []byte          `json:"-"` // migrated file contents
+
+	// Normalized is Before re-encoded in canonical form, so diffing it
+	// against After shows only what the migrations changed.
+	Normalized []byte `json:"-"`
+}
+
+// NeedsMigration reports whether any migration steps would be applied.
+func (p *MigrationPlan) NeedsMigration() bool { return len(p.Steps) > 0 }
+
+// SchemaError is returned when the registry was written by a newer devinfra
+// than this binary understands.
+type SchemaError struct {
+	Found     int
+	Supported int
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("projects.yaml uses schema version %d but this di only supports up to %d; upgrade di before making changes", e.Found, e.Supported)
+}
+
+// RegistryBackupPath returns the path of the backup taken before migrating a
+// registry from the given schema version.
+func RegistryBackupPath(fromVersion int) string {
+	return filepath.Join(ConfigDir(), fmt.Sprintf("projects.yaml.v%d.bak", fromVersion))
+}
+
+// PlanRegistryMigration reads projects.yaml and computes the migrations needed
+// to bring it to CurrentSchemaVersion without writing anything.
+func PlanRegistryMigration() (*MigrationPlan, error) {
+	data, err := os.ReadFile(RegistryPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &MigrationPlan{From: CurrentSchemaVersion, To: CurrentSchemaVersion}, nil
+		}
+		return nil, fmt.Errorf("reading registry: %w", err)
+	}
+	return planMigration(data)
+}
+
+// MigrateRegistry upgrades projects.yaml in place under the config directory
+// lock, keeping a backup of the original file. It is a no-op when the registry
+// is already current.
+func MigrateRegistry() (*MigrationPlan, error) {
+	var plan *MigrationPlan
+	err := WithLock(func() error {
+		var err error
+		plan, err = PlanRegistryMigration()
+		if err != nil || !plan.NeedsMigration() {
+			return err
+		}
+		if err := backupRegistry(plan.Before, plan.From); err != nil {
+			return err
+		}
+		return writeFileAtomic(RegistryPath(), plan.After)
+	})
+	return plan, err
+}
+
+// planMigration applies all pending migrations to a raw registry document.
+func planMigration(data []byte) (*MigrationPlan, error) {
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing registry: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	from := schemaVersionOf(doc)
+	plan := &MigrationPlan{From: from, To: from, Before: data, After: data}
+	if from > CurrentSchemaVersion {
+		return nil, &SchemaError{Found: from, Supported: CurrentSchemaVersion}
+	}
+
+	for _, m := range registryMigrations {
+		if m.version <= from {
+			continue
+		}
+		if err := m.apply(doc); err != nil {
+			return nil, fmt.Errorf("migrating registry to schema version %d: %w", m.version, err)
+		}
+		doc["schema_version"] = m.version
+		plan.To = m.version
+		plan.Steps = append(plan.Steps, MigrationStep{Version: m.version, Description: m.description})
+	}
+
+	if plan.NeedsMigration() {
+		// Round-trip through the typed struct so the output has canonical
+		// field order, matching what SaveRegistry would write.
+		raw, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling migrated registry: %w", err)
+		}
+		if plan.After, err = canonicalRegistry(raw); err != nil {
+			return nil, fmt.Errorf("migrated registry: %w", err)
+		}
+		if plan.Normalized, err = canonicalRegistry(data); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// canonicalRegistry re-encodes raw registry YAML through the Registry struct.
+func canonicalRegistry(data []byte) ([]byte, error) {
+	var reg Registry
+	if err := yaml.Unmarshal(data, &reg); err != nil {
+		return nil, fmt.Errorf("parsing registry: %w", err)
+	}
+	out, err := yaml.Marshal(&reg)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling registry: %w", err)
+	}
+	return out, nil
+}
+
+// schemaVersionOf returns the schema_version recorded in a raw registry
+// document. Registries written before versioning was introduced have none and
+// are treated as version 0.
+func schemaVersionOf(doc map[string]any) int {
+	if v, ok := doc["schema_version"].(int); ok {
+		return v
+	}
+	return 0
+}
+
+// diskRegistry returns the contents of the registry currently on disk and its
+// schema version, or nil and 0 if it is missing or unreadable.
+func diskRegistry() ([]byte, int) {
+	data, err := os.ReadFile(RegistryPath())
+	if err != nil {
+		return nil, 0
+	}
+	var doc struct {
+		SchemaVersion int `yaml:"schema_version"`
+	}
+	_ = yaml.Unmarshal(data, &doc)
+	return data, doc.SchemaVersion
+}
+
+// backupRegistry saves the pre-migration registry contents, keeping the first
+// backup taken for a given version rather than overwriting it.
+func backupRegistry(data []byte, fromVersion int) error {
+	path := RegistryBackupPath(fromVersion)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("backing up registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadRegistryMigratesLegacy(t *testing.T) {
+	t.Setenv("DEVINFRA_HOME", t.TempDir())
+
+	legacy := "projects:\n  - name: myapp\n    dir: /tmp/myapp\n"
+	if err := os.WriteFile(RegistryPath(), []byte(legacy), 0644); err != nil {
+		t.Fatalf("writing registry: %v", err)
+	}
+
+	plan, err := PlanRegistryMigration()
+	if err != nil {
+		t.Fatalf("PlanRegistryMigration: %v", err)
+	}
+	if plan.From != 0 || plan.To != CurrentSchemaVersion || !plan.NeedsMigration() {
+		t.Errorf("plan = %d→%d (%d steps), want 0→%d", plan.From, plan.To, len(plan.Steps), CurrentSchemaVersion)
+	}
+
+	reg, err := LoadRegistry()
+	if err != nil {
+		t.Fatalf("LoadRegistry: %v", err)
+	}
+	if reg.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", reg.SchemaVersion, CurrentSchemaVersion)
+	}
+	if reg.Get("myapp") == nil {
+		t.Errorf("migrated registry lost project myapp")
+	}
+	if _, err := os.Stat(RegistryBackupPath(0)); !os.IsNotExist(err) {
+		t.Errorf("LoadRegistry wrote a backup; reads must not write")
+	}
+
+	if err := SaveRegistry(reg); err != nil {
+		t.Fatalf("SaveRegistry: %v", err)
+	}
+	backup, err := os.ReadFile(RegistryBackupPath(0))
+	if err != nil {
+		t.Fatalf("reading backup: %v", err)
+	}
+	if string(backup) != legacy {
+		t.Errorf("backup = %q, want original contents", backup)
+	}
+}
+
+func TestSaveRegistryRefusesNewerSchema(t *testing.T) {
+	t.Setenv("DEVINFRA_HOME", t.TempDir())
+
+	future := "schema_version: 999\nprojects:\n  - name: myapp\n    dir: /tmp/myapp\n    future_field: x\n"
+	if err := os.WriteFile(RegistryPath(), []byte(future), 0644); err != nil {
+		t.Fatalf("writing registry: %v", err)
+	}
+
+	reg, err := LoadRegistry()
+	if err != nil {
+		t.Fatalf("LoadRegistry should still read a newer registry: %v", err)
+	}
+	if reg.Get("myapp") == nil {
+		t.Errorf("newer registry lost project myapp on read")
+	}
+
+	var schemaErr *SchemaError
+	if err := SaveRegistry(reg); !errors.As(err, &schemaErr) {
+		t.Fatalf("SaveRegistry error = %v, want *SchemaError", err)
+	}
+
+	data, _ := os.ReadFile(RegistryPath())
+	if !strings.Contains(string(data), "future_field") {
+		t.Errorf("newer registry was overwritten")
+	}
+}
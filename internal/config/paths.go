@@ -4,7 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
+	"strconv"
 
 	"github.com/adrg/xdg"
 )
@@ -25,6 +25,9 @@ func RegistryPath() string  { return filepath.Join(ConfigDir(), "projects.yaml")
 func EnvFilePath() string   { return filepath.Join(ConfigDir(), ".env") }
 func ComposeFile() string   { return filepath.Join(ComposeDir(), "docker-compose.yaml") }
 func DnsmasqConf() string   { return filepath.Join(ComposeDir(), "dnsmasq.conf") }
+func LockPath() string      { return filepath.Join(ConfigDir(), ".lock") }
+func LogsDir() string       { return filepath.Join(ConfigDir(), "logs") }
+func RunDir() string        { return filepath.Join(ConfigDir(), "run") }
 
 // IsInitialized returns true if the config directory and compose file exist.
 func IsInitialized() bool {
@@ -32,30 +35,14 @@ func IsInitialized() bool {
 	return err == nil
 }
 
-// DNSPort reads DNS_PORT from environment or returns the default.
+// DNSPort returns the configured dnsmasq host port.
 func DNSPort() string {
-	if p := os.Getenv("DNS_PORT"); p != "" {
-		return p
-	}
-	return "5354"
+	return strconv.Itoa(Current().DNSPort)
 }
 
-// TLD reads the configured local TLD from the TLD environment variable,
-// falls back to parsing the .env file, and defaults to "test".
+// TLD returns the configured local TLD.
 func TLD() string {
-	if t := os.Getenv("TLD"); t != "" {
-		return t
-	}
-	if data, err := os.ReadFile(EnvFilePath()); err == nil {
-		for _, line := range strings.Split(string(data), "\n") {
-			if v, ok := strings.CutPrefix(line, "TLD="); ok {
-				if t := strings.TrimSpace(v); t != "" {
-					return t
-				}
-			}
-		}
-	}
-	return "test"
+	return Current().TLD
 }
 
 // EnsureDirs creates all required directories with appropriate permissions.
@@ -1,31 +1,176 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Service struct {
-	Name string `yaml:"name" json:"name"`
-	Port int    `yaml:"port" json:"port"`
+	Name      string `yaml:"name" json:"name"`
+	Port      int    `yaml:"port" json:"port"`
+	Subdomain string `yaml:"subdomain,omitempty" json:"subdomain,omitempty"` // custom subdomain; "@" = root domain
+	Host      bool   `yaml:"host,omitempty" json:"host,omitempty"`           // runs on host, not in docker
+	Routing   string `yaml:"routing,omitempty" json:"routing,omitempty"`     // how to treat Traefik routers already in the compose file
+	Command   string `yaml:"command,omitempty" json:"command,omitempty"`     // host services: shell command di runs and supervises
+	Workdir   string `yaml:"workdir,omitempty" json:"workdir,omitempty"`     // host services: command directory, relative to the project dir
+}
+
+// Routing modes for docker services whose compose file already defines
+// Traefik routers.
+const (
+	RoutingGenerate = ""      // add a devinfra router next to any existing ones
+	RoutingAdopt    = "adopt" // reuse the existing routers, rewriting their host rules to the devinfra domains
+	RoutingSkip     = "skip"  // leave the existing routers alone and generate none
+)
+
+// RootSubdomain is the Subdomain value that routes a service on the bare
+// project domain (e.g. myapp.test) instead of a subdomain of it.
+const RootSubdomain = "@"
+
+// ServiceSubdomain returns the effective subdomain (service name if not overridden).
+func (s Service) ServiceSubdomain() string {
+	if s.Subdomain != "" {
+		return s.Subdomain
+	}
+	return s.Name
 }
 
 type Project struct {
-	Name        string    `yaml:"name" json:"name"`
-	Dir         string    `yaml:"dir" json:"dir"`
-	Domain      string    `yaml:"domain" json:"domain"`
-	HostMode    bool      `yaml:"host_mode" json:"host_mode"`
-	Services    []Service `yaml:"services" json:"services"`
-	Flavors     []string  `yaml:"flavors,omitempty" json:"flavors,omitempty"`
-	ComposeFile string    `yaml:"compose_file,omitempty" json:"compose_file,omitempty"`
-	Created     string    `yaml:"created_at" json:"created_at"`
+	Name             string    `yaml:"name" json:"name"`
+	Dir              string    `yaml:"dir" json:"dir"`
+	Domain           string    `yaml:"domain" json:"domain"`
+	ExtraDomains     []string  `yaml:"extra_domains,omitempty" json:"extra_domains,omitempty"`
+	HostMode         bool      `yaml:"host_mode" json:"host_mode"`
+	Services         []Service `yaml:"services" json:"services"`
+	Flavors          []string  `yaml:"flavors,omitempty" json:"flavors,omitempty"`
+	ComposeFile      string    `yaml:"compose_file,omitempty" json:"compose_file,omitempty"`
+	ComposeOverrides []string  `yaml:"compose_overrides,omitempty" json:"compose_overrides,omitempty"`
+	Tags             []string  `yaml:"tags,omitempty" json:"tags,omitempty"`
+	DependsOn        []string  `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+	Profiles         []string  `yaml:"profiles,omitempty" json:"profiles,omitempty"` // compose profiles enabled by default on 'di up'
+	Created          string    `yaml:"created_at" json:"created_at"`
+}
+
+// BaseDomains returns the project's domains stripped of the "*." prefix,
+// primary domain first.
+func (p Project) BaseDomains() []string {
+	primary := strings.TrimPrefix(p.Domain, "*.")
+	if primary == "" {
+		primary = fmt.Sprintf("%s.%s", p.Name, TLD())
+	}
+	domains := []string{primary}
+	for _, d := range p.ExtraDomains {
+		domains = append(domains, strings.TrimPrefix(d, "*."))
+	}
+	return domains
+}
+
+// ServiceHosts returns every hostname svc is routed on under the given base
+// domains. A "@" subdomain routes the bare base domain. When no service claims
+// the root, the first service also answers on it.
+func (p Project) ServiceHosts(svc Service, bases []string) []string {
+	var hosts []string
+	sub := svc.ServiceSubdomain()
+	if sub != RootSubdomain && p.rootService() == svc.Name {
+		hosts = append(hosts, bases...)
+	}
+	for _, base := range bases {
+		if sub == RootSubdomain {
+			hosts = append(hosts, base)
+		} else {
+			hosts = append(hosts, fmt.Sprintf("%s.%s", sub, base))
+		}
+	}
+	return hosts
+}
+
+// URLs returns the project's local HTTPS URLs across all of its base domains,
+// in service order and without duplicates. A project without services still
+// reports its primary domain.
+func (p Project) URLs() []string {
+	return p.URLsFor(p.BaseDomains())
+}
+
+// URLsFor returns the project's HTTPS URLs under the given base domains.
+func (p Project) URLsFor(bases []string) []string {
+	if len(p.Services) == 0 {
+		return []string{"https://" + bases[0]}
+	}
+	seen := make(map[string]bool)
+	var urls []string
+	for _, svc := range p.Services {
+		for _, host := range p.ServiceHosts(svc, bases) {
+			if !seen[host] {
+				seen[host] = true
+				urls = append(urls, "https://"+host)
+			}
+		}
+	}
+	return urls
+}
+
+// rootService returns the name of the service routed on the bare domain: the
+// first one with a "@" subdomain, or else the first service.
+func (p Project) rootService() string {
+	for _, s := range p.Services {
+		if s.Subdomain == RootSubdomain {
+			return s.Name
+		}
+	}
+	if len(p.Services) > 0 {
+		return p.Services[0].Name
+	}
+	return ""
+}
+
+// Mode describes where the project's services run: "docker", "host", or
+// "mixed" when some services run on the host next to the containers.
+func (p Project) Mode() string {
+	if p.HostMode {
+		return "host"
+	}
+	for _, s := range p.Services {
+		if s.Host {
+			return "mixed"
+		}
+	}
+	return "docker"
+}
+
+// HostServices returns the services served from the host through Traefik's
+// file provider: all of them in host mode, otherwise those marked host.
+func (p Project) HostServices() []Service {
+	var out []Service
+	for _, s := range p.Services {
+		if p.HostMode || s.Host {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// DockerServices returns the services routed through Docker labels.
+func (p Project) DockerServices() []Service {
+	if p.HostMode {
+		return nil
+	}
+	var out []Service
+	for _, s := range p.Services {
+		if !s.Host {
+			out = append(out, s)
+		}
+	}
+	return out
 }
 
 // ComposeFiles returns the full list of compose files for this project,
-// including the base compose file, devinfra overlay, and flavor overlays.
+// including the base compose file, any project-declared overrides, the
+// devinfra overlay, and flavor overlays.
 func (p Project) ComposeFiles() []string {
 	base := "docker-compose.yaml"
 	if p.ComposeFile != "" {
@@ -33,6 +178,10 @@ func (p Project) ComposeFiles() []string {
 	}
 	files := []string{filepath.Join(p.Dir, base)}
 
+	for _, f := range p.ComposeOverrides {
+		files = append(files, filepath.Join(p.Dir, f))
+	}
+
 	// Include devinfra overlay if it exists
 	devinfra := filepath.Join(p.Dir, "docker-compose.devinfra.yaml")
 	if _, err := os.Stat(devinfra); err == nil {
@@ -46,21 +195,39 @@ func (p Project) ComposeFiles() []string {
 }
 
 type Registry struct {
-	Projects []Project `yaml:"projects"`
+	SchemaVersion int       `yaml:"schema_version"`
+	Projects      []Project `yaml:"projects"`
 }
 
 // LoadRegistry reads and parses the projects.yaml file.
 // Returns an empty registry if the file doesn't exist.
+//
+// Registries written with an older schema are migrated in memory; the
+// upgraded form is persisted, and the original backed up, on the next save.
+// LoadRegistry itself never writes, so it is safe without the config lock.
+// Registries written by a newer di are loaded as-is for reading, but
+// SaveRegistry will refuse to write them back.
 func LoadRegistry() (*Registry, error) {
 	path := RegistryPath()
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &Registry{}, nil
+			return &Registry{SchemaVersion: CurrentSchemaVersion}, nil
 		}
 		return nil, fmt.Errorf("reading registry: %w", err)
 	}
 
+	plan, err := planMigration(data)
+	var schemaErr *SchemaError
+	switch {
+	case errors.As(err, &schemaErr):
+		// Newer than we understand: read what we can, never write.
+	case err != nil:
+		return nil, err
+	case plan.NeedsMigration():
+		data = plan.After
+	}
+
 	var reg Registry
 	if err := yaml.Unmarshal(data, &reg); err != nil {
 		return nil, fmt.Errorf("parsing registry: %w", err)
@@ -70,17 +237,38 @@ func LoadRegistry() (*Registry, error) {
 
 // SaveRegistry writes the registry to projects.yaml using atomic write
 // (write to temp file, then rename) to prevent corruption on crash.
+// It refuses to overwrite a registry written with a newer schema version, and
+// backs up one written with an older version before upgrading it. Callers
+// hold the config directory lock.
 func SaveRegistry(reg *Registry) error {
+	disk, diskVersion := diskRegistry()
+	for _, v := range []int{reg.SchemaVersion, diskVersion} {
+		if v > CurrentSchemaVersion {
+			return &SchemaError{Found: v, Supported: CurrentSchemaVersion}
+		}
+	}
+	reg.SchemaVersion = CurrentSchemaVersion
+
 	if err := EnsureDirs(); err != nil {
 		return fmt.Errorf("creating config directory: %w", err)
 	}
+	if disk != nil && diskVersion < CurrentSchemaVersion {
+		if err := backupRegistry(disk, diskVersion); err != nil {
+			return err
+		}
+	}
 	data, err := yaml.Marshal(reg)
 	if err != nil {
 		return fmt.Errorf("marshaling registry: %w", err)
 	}
-	path := RegistryPath()
+	return writeFileAtomic(RegistryPath(), data)
+}
+
+// writeFileAtomic writes data to a temp file in the same directory, syncs it,
+// and renames it over path.
+func writeFileAtomic(path string, data []byte) error {
 	dir := filepath.Dir(path)
-	tmp, err := os.CreateTemp(dir, ".projects.yaml.tmp.*")
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp.*")
 	if err != nil {
 		return fmt.Errorf("creating temp file: %w", err)
 	}
@@ -88,12 +276,12 @@ func SaveRegistry(reg *Registry) error {
 	if _, err := tmp.Write(data); err != nil {
 		_ = tmp.Close()
 		_ = os.Remove(tmpName)
-		return fmt.Errorf("writing registry: %w", err)
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
 	}
 	if err := tmp.Sync(); err != nil {
 		_ = tmp.Close()
 		_ = os.Remove(tmpName)
-		return fmt.Errorf("syncing registry: %w", err)
+		return fmt.Errorf("syncing %s: %w", filepath.Base(path), err)
 	}
 	if err := tmp.Close(); err != nil {
 		_ = os.Remove(tmpName)
@@ -101,11 +289,31 @@ func SaveRegistry(reg *Registry) error {
 	}
 	if err := os.Rename(tmpName, path); err != nil {
 		_ = os.Remove(tmpName)
-		return fmt.Errorf("renaming registry: %w", err)
+		return fmt.Errorf("renaming %s: %w", filepath.Base(path), err)
 	}
 	return nil
 }
 
+// UpdateRegistry loads the registry, applies fn, and saves the result while
+// holding the config directory lock, so concurrent di invocations cannot
+// silently overwrite each other's changes. If fn returns an error the registry
+// is not saved.
+func UpdateRegistry(fn func(reg *Registry) error) error {
+	return WithLock(func() error {
+		reg, err := LoadRegistry()
+		if err != nil {
+			return err
+		}
+		if err := fn(reg); err != nil {
+			return err
+		}
+		if err := SaveRegistry(reg); err != nil {
+			return fmt.Errorf("saving registry: %w", err)
+		}
+		return nil
+	})
+}
+
 // Get returns a project by name, or nil if not found.
 func (r *Registry) Get(name string) *Project {
 	for i := range r.Projects {
@@ -116,12 +324,17 @@ func (r *Registry) Get(name string) *Project {
 	return nil
 }
 
-// Add appends a project to the registry.
+// Add appends a project to the registry. It fails if the project's
+// dependencies would form a cycle.
 func (r *Registry) Add(p Project) error {
 	if existing := r.Get(p.Name); existing != nil {
 		return fmt.Errorf("project %q already exists", p.Name)
 	}
 	r.Projects = append(r.Projects, p)
+	if err := r.CheckCycles(); err != nil {
+		r.Projects = r.Projects[:len(r.Projects)-1]
+		return err
+	}
 	return nil
 }
 
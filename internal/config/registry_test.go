@@ -0,0 +1,61 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestServiceHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []Service
+		svc      int
+		want     []string
+	}{
+		{
+			name:     "first service also answers on root",
+			services: []Service{{Name: "web", Port: 3000}, {Name: "api", Port: 4000}},
+			svc:      0,
+			want:     []string{"myapp.test", "myapp.example.dev", "web.myapp.test", "web.myapp.example.dev"},
+		},
+		{
+			name:     "later service gets only its subdomain",
+			services: []Service{{Name: "web", Port: 3000}, {Name: "api", Port: 4000}},
+			svc:      1,
+			want:     []string{"api.myapp.test", "api.myapp.example.dev"},
+		},
+		{
+			name:     "custom subdomain",
+			services: []Service{{Name: "web", Port: 3000}, {Name: "support-site", Port: 5175, Subdomain: "docs"}},
+			svc:      1,
+			want:     []string{"docs.myapp.test", "docs.myapp.example.dev"},
+		},
+		{
+			name:     "root subdomain claims the bare domain",
+			services: []Service{{Name: "api", Port: 4000}, {Name: "frontend", Port: 5173, Subdomain: "@"}},
+			svc:      1,
+			want:     []string{"myapp.test", "myapp.example.dev"},
+		},
+		{
+			name:     "first service loses root to an explicit claim",
+			services: []Service{{Name: "api", Port: 4000}, {Name: "frontend", Port: 5173, Subdomain: "@"}},
+			svc:      0,
+			want:     []string{"api.myapp.test", "api.myapp.example.dev"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Project{
+				Name:         "myapp",
+				Domain:       "*.myapp.test",
+				ExtraDomains: []string{"*.myapp.example.dev"},
+				Services:     tt.services,
+			}
+			got := p.ServiceHosts(tt.services[tt.svc], p.BaseDomains())
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ServiceHosts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -14,20 +14,9 @@ type RemoteConfig struct {
 	CloudflareToken string
 }
 
-// Remote reads the remote domain configuration from the environment and .env file.
-// Environment variables take precedence over .env file values.
+// Remote returns the configured remote domain settings.
 func Remote() RemoteConfig {
-	var r RemoteConfig
-
-	env := readEnvFile()
-
-	r.Enabled = parseBool(getEnvOrFile("REMOTE_ENABLED", env))
-	r.Domain = getEnvOrFile("REMOTE_DOMAIN", env)
-	r.DNSProvider = getEnvOrFile("REMOTE_DNS_PROVIDER", env)
-	r.ACMEEmail = getEnvOrFile("REMOTE_ACME_EMAIL", env)
-	r.CloudflareToken = getEnvOrFile("CF_DNS_API_TOKEN", env)
-
-	return r
+	return Current().Remote
 }
 
 // RemoteEnabled returns true if the remote domain feature is enabled.
@@ -55,15 +44,6 @@ func readEnvFile() map[string]string {
 	return m
 }
 
-// getEnvOrFile returns the value of an environment variable, falling back to
-// the parsed .env file map.
-func getEnvOrFile(key string, env map[string]string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-	return env[key]
-}
-
 // parseBool returns true for "true", "1", "yes" (case-insensitive).
 func parseBool(s string) bool {
 	switch strings.ToLower(strings.TrimSpace(s)) {
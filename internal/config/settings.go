@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Settings is the typed devinfra configuration for one invocation. Each value
+// is resolved once, in order of precedence: a flag named after the key on the
+// command being run (only init has one, --tld), environment variables, the
+// .env file in the config directory, then built-in defaults. Commands pass it
+// down to the compose, project and doctor code that needs it.
+type Settings struct {
+	TLD           string
+	Runtime       string
+	RuntimeSocket string // empty means the runtime's default socket
+	DNSPort       int
+	DNSUpstream   []string
+	DNSForward    []DNSForward
+	TCPRouting    bool // route flavor services through Traefik TCP entrypoints
+	Remote        RemoteConfig
+
+	// Values records each key's resolved value and source.
+	Values []Value
+}
+
+var (
+	settingsMu    sync.Mutex
+	settings      *Settings
+	flagOverrides map[string]string
+)
+
+// LoadSettings resolves and validates every configuration key. flags maps key
+// names (e.g. "tld") to values given on the command line. Invalid values are
+// reported in the returned error; the returned Settings is always usable and
+// falls back to the default for any value that failed validation.
+func LoadSettings(flags map[string]string) (*Settings, error) {
+	env := readEnvFile()
+	s := &Settings{}
+	var errs []error
+
+	for _, k := range Keys {
+		v := k.resolve(env, flags)
+		s.Values = append(s.Values, v)
+		if v.Error != "" {
+			errs = append(errs, fmt.Errorf("%s (from %s): %s", k.Name, v.Source, v.Error))
+			v.Value = k.Default
+		}
+
+		switch k.Name {
+		case "tld":
+			s.TLD = v.Value
+		case "runtime":
+			s.Runtime = v.Value
+		case "runtime.socket":
+			s.RuntimeSocket = v.Value
+		case "dns.port":
+			s.DNSPort, _ = strconv.Atoi(v.Value)
+		case "dns.upstream":
+			s.DNSUpstream, _ = ParseDNSServers(v.Value)
+		case "dns.forward":
+			s.DNSForward, _ = ParseDNSForwards(v.Value)
+		case "tcp.routing":
+			s.TCPRouting = parseBool(v.Value)
+		case "remote.enabled":
+			s.Remote.Enabled = parseBool(v.Value)
+		case "remote.domain":
+			s.Remote.Domain = v.Value
+		case "remote.dns_provider":
+			s.Remote.DNSProvider = v.Value
+		case "remote.acme_email":
+			s.Remote.ACMEEmail = v.Value
+		case "remote.cloudflare_zone_token":
+			s.Remote.CloudflareToken = v.Value
+		}
+	}
+
+	if len(errs) > 0 {
+		return s, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return s, nil
+}
+
+// Load resolves the settings for this invocation and makes them available
+// through Current. flags are remembered so Reload keeps honoring them.
+func Load(flags map[string]string) (*Settings, error) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	flagOverrides = flags
+	s, err := LoadSettings(flags)
+	settings = s
+	return s, err
+}
+
+// Reload re-reads the settings after .env has been changed by this process.
+func Reload() (*Settings, error) {
+	settingsMu.Lock()
+	flags := flagOverrides
+	settingsMu.Unlock()
+	return Load(flags)
+}
+
+// Current returns the settings loaded for this invocation, loading them
+// without flag overrides on first use.
+func Current() *Settings {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if settings == nil {
+		settings, _ = LoadSettings(flagOverrides)
+	}
+	return settings
+}
+
+// ComposeEnv returns the environment for project docker compose commands: the
+// process environment plus DNS_PORT.
+func (s *Settings) ComposeEnv() []string {
+	return append(os.Environ(), "DNS_PORT="+strconv.Itoa(s.DNSPort))
+}
+
+// InfraEnv returns the environment for the devinfra infra compose project,
+// which additionally gets CF_DNS_API_TOKEN when remote is enabled.
+func (s *Settings) InfraEnv() []string {
+	env := s.ComposeEnv()
+	if s.Remote.Enabled && s.Remote.CloudflareToken != "" {
+		env = append(env, "CF_DNS_API_TOKEN="+s.Remote.CloudflareToken)
+	}
+	return env
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadSettingsPrecedence(t *testing.T) {
+	t.Setenv("DEVINFRA_HOME", t.TempDir())
+	t.Setenv("TLD", "")
+	t.Setenv("DNS_PORT", "")
+	t.Setenv("REMOTE_DOMAIN", "env.example.dev")
+	env := " TLD = claw\nDNS_PORT=5400\nREMOTE_DOMAIN=file.example.dev\nREMOTE_ENABLED=true\n"
+	if err := os.WriteFile(EnvFilePath(), []byte(env), 0600); err != nil {
+		t.Fatalf("writing .env: %v", err)
+	}
+
+	s, err := LoadSettings(map[string]string{"dns.port": "5500"})
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if s.TLD != "claw" {
+		t.Errorf("TLD = %q, want claw from .env", s.TLD)
+	}
+	if s.DNSPort != 5500 {
+		t.Errorf("DNSPort = %d, want 5500 from flag", s.DNSPort)
+	}
+	if s.Remote.Domain != "env.example.dev" {
+		t.Errorf("Remote.Domain = %q, want env.example.dev from environment", s.Remote.Domain)
+	}
+	if !s.Remote.Enabled || s.Remote.ACMEEmail != "" {
+		t.Errorf("Remote = %+v, want enabled with no ACME email", s.Remote)
+	}
+}
+
+func TestLoadSettingsInvalid(t *testing.T) {
+	t.Setenv("DEVINFRA_HOME", t.TempDir())
+	t.Setenv("TLD", "Not_Valid")
+	t.Setenv("DNS_PORT", "")
+
+	s, err := LoadSettings(nil)
+	if err == nil {
+		t.Fatal("LoadSettings: expected error for invalid TLD")
+	}
+	if s.TLD != "test" || s.DNSPort != 5354 {
+		t.Errorf("settings = %q/%d, want defaults test/5354", s.TLD, s.DNSPort)
+	}
+}
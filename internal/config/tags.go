@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ValidateTag checks that a project tag is a lowercase DNS-style label.
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+	if len(tag) > 63 || !tldLabelRe.MatchString(tag) {
+		return fmt.Errorf("tag %q is invalid: must contain only lowercase letters, digits, and hyphens", tag)
+	}
+	return nil
+}
+
+// HasTag reports whether the project carries tag.
+func (p Project) HasTag(tag string) bool {
+	return slices.Contains(p.Tags, tag)
+}
+
+// ProjectFilter selects projects by tag for group operations.
+type ProjectFilter struct {
+	// Tags matches projects carrying any of the listed tags.
+	Tags []string
+	// Selector is a comma-separated list of terms that must all hold:
+	// "billing" requires the tag, "!legacy" excludes it.
+	Selector string
+}
+
+// IsZero reports whether the filter has no criteria.
+func (f ProjectFilter) IsZero() bool {
+	return len(f.Tags) == 0 && f.Selector == ""
+}
+
+// Validate checks the tags and selector terms.
+func (f ProjectFilter) Validate() error {
+	for _, t := range f.Tags {
+		if err := ValidateTag(t); err != nil {
+			return err
+		}
+	}
+	if f.Selector == "" {
+		return nil
+	}
+	for _, term := range strings.Split(f.Selector, ",") {
+		if err := ValidateTag(strings.TrimPrefix(strings.TrimSpace(term), "!")); err != nil {
+			return fmt.Errorf("selector %q: %w", f.Selector, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether p satisfies the filter.
+func (f ProjectFilter) Matches(p Project) bool {
+	if len(f.Tags) > 0 && !slices.ContainsFunc(f.Tags, p.HasTag) {
+		return false
+	}
+	if f.Selector == "" {
+		return true
+	}
+	for _, term := range strings.Split(f.Selector, ",") {
+		term = strings.TrimSpace(term)
+		if tag, negated := strings.CutPrefix(term, "!"); negated {
+			if p.HasTag(tag) {
+				return false
+			}
+		} else if !p.HasTag(term) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the projects matching f, in registry order.
+func (r *Registry) Filter(f ProjectFilter) ([]Project, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	var out []Project
+	for _, p := range r.Projects {
+		if f.Matches(p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// Tags returns every tag used by a registered project, sorted.
+func (r *Registry) Tags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, p := range r.Projects {
+		for _, t := range p.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegistryFilter(t *testing.T) {
+	reg := &Registry{Projects: []Project{
+		{Name: "billing-api", Tags: []string{"billing", "backend"}},
+		{Name: "billing-web", Tags: []string{"billing", "frontend"}},
+		{Name: "legacy-billing", Tags: []string{"billing", "legacy"}},
+		{Name: "blog"},
+	}}
+
+	tests := []struct {
+		name    string
+		filter  ProjectFilter
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "single tag",
+			filter: ProjectFilter{Tags: []string{"billing"}},
+			want:   []string{"billing-api", "billing-web", "legacy-billing"},
+		},
+		{
+			name:   "any of several tags",
+			filter: ProjectFilter{Tags: []string{"frontend", "legacy"}},
+			want:   []string{"billing-web", "legacy-billing"},
+		},
+		{
+			name:   "selector requires all terms",
+			filter: ProjectFilter{Selector: "billing,backend"},
+			want:   []string{"billing-api"},
+		},
+		{
+			name:   "selector exclusion",
+			filter: ProjectFilter{Tags: []string{"billing"}, Selector: "!legacy"},
+			want:   []string{"billing-api", "billing-web"},
+		},
+		{
+			name:    "invalid selector term",
+			filter:  ProjectFilter{Selector: "billing,Bad Tag"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reg.Filter(tt.filter)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Filter: expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Filter: %v", err)
+			}
+			var names []string
+			for _, p := range got {
+				names = append(names, p.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Filter = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -43,10 +44,14 @@ func ValidateName(name string) error {
 	return nil
 }
 
-var reservedPorts = map[int]bool{
-	80:   true,
-	443:  true,
-	5354: true,
+// traefikPorts are the host ports bound by Traefik's web and websecure
+// entrypoints.
+var traefikPorts = []int{80, 443}
+
+// ReservedPorts returns the host ports devinfra itself binds: Traefik's
+// entrypoints and the configured dnsmasq port.
+func ReservedPorts() []int {
+	return append(slices.Clone(traefikPorts), Current().DNSPort)
 }
 
 // ValidatePort checks that a port number is valid and not reserved.
@@ -54,12 +59,73 @@ func ValidatePort(port int) error {
 	if port < 1 || port > 65535 {
 		return fmt.Errorf("port must be between 1 and 65535")
 	}
-	if reservedPorts[port] {
+	if slices.Contains(ReservedPorts(), port) {
 		return fmt.Errorf("port %d is reserved by dev-infra", port)
 	}
 	return nil
 }
 
+// ValidateServices checks each service's name, port, subdomain, and workdir,
+// and that no two services share a name, port, or subdomain.
+func ValidateServices(services []Service) error {
+	seenNames := make(map[string]bool)
+	seenPorts := make(map[int]bool)
+	seenSubdomains := make(map[string]bool)
+	for _, svc := range services {
+		if err := ValidateName(svc.Name); err != nil {
+			return fmt.Errorf("service name %q: %w", svc.Name, err)
+		}
+		if err := ValidatePort(svc.Port); err != nil {
+			return fmt.Errorf("service %q: %w", svc.Name, err)
+		}
+		if svc.Subdomain != "" {
+			if err := ValidateSubdomain(svc.Subdomain); err != nil {
+				return fmt.Errorf("service %q: %w", svc.Name, err)
+			}
+		}
+		switch svc.Routing {
+		case RoutingGenerate, RoutingAdopt, RoutingSkip:
+		default:
+			return fmt.Errorf("service %q: routing must be %q or %q", svc.Name, RoutingAdopt, RoutingSkip)
+		}
+		if svc.Routing != RoutingGenerate && svc.Host {
+			return fmt.Errorf("service %q: routing only applies to docker services", svc.Name)
+		}
+		if svc.Workdir != "" {
+			if svc.Command == "" {
+				return fmt.Errorf("service %q: workdir requires a command", svc.Name)
+			}
+			if !filepath.IsLocal(svc.Workdir) {
+				return fmt.Errorf("service %q: workdir %q must be a path inside the project directory", svc.Name, svc.Workdir)
+			}
+		}
+		if seenNames[svc.Name] {
+			return fmt.Errorf("duplicate service name: %s", svc.Name)
+		}
+		if seenPorts[svc.Port] {
+			return fmt.Errorf("duplicate port: %d", svc.Port)
+		}
+		if seenSubdomains[svc.ServiceSubdomain()] {
+			return fmt.Errorf("duplicate subdomain: %s", svc.ServiceSubdomain())
+		}
+		seenNames[svc.Name] = true
+		seenPorts[svc.Port] = true
+		seenSubdomains[svc.ServiceSubdomain()] = true
+	}
+	return nil
+}
+
+// profileRe matches compose profile names.
+var profileRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidateProfile checks that a compose profile name is one compose accepts.
+func ValidateProfile(profile string) error {
+	if !profileRe.MatchString(profile) {
+		return fmt.Errorf("profile %q is invalid: must start with a letter or digit and contain only letters, digits, '_', '.', and '-'", profile)
+	}
+	return nil
+}
+
 // ParsePort parses a port string into an integer and validates it.
 func ParsePort(s string) (int, error) {
 	port, err := strconv.Atoi(s)
@@ -127,6 +193,32 @@ func ValidateRemoteDomain(domain string) error {
 	return nil
 }
 
+// ValidateSubdomain checks that a service subdomain is a single DNS label, or
+// "@" for the project's root domain.
+func ValidateSubdomain(sub string) error {
+	if sub == RootSubdomain {
+		return nil
+	}
+	if len(sub) > 63 || !tldLabelRe.MatchString(sub) {
+		return fmt.Errorf("subdomain %q is invalid: must be a single DNS label (e.g. api) or %q for the root domain", sub, RootSubdomain)
+	}
+	return nil
+}
+
+// ValidateExtraDomain checks an extra project domain such as
+// "*.myapp.example.dev". The "*." prefix is optional; certs always cover both
+// the base domain and its wildcard.
+func ValidateExtraDomain(domain string) error {
+	base := strings.TrimPrefix(domain, "*.")
+	if !domainRe.MatchString(base) {
+		return fmt.Errorf("domain %q is invalid: must be a DNS name, optionally prefixed with *. (e.g. *.myapp.example.dev)", domain)
+	}
+	if strings.HasSuffix(base, "."+TLD()) {
+		return fmt.Errorf("domain %q is under the local .%s TLD, which every project already gets", domain, TLD())
+	}
+	return nil
+}
+
 // ValidateACMEEmail checks that email looks like a basic email address.
 func ValidateACMEEmail(email string) error {
 	if email == "" {
@@ -145,6 +237,24 @@ func ValidateACMEEmail(email string) error {
 // ValidateTLD checks that tld is a valid DNS label and emits warnings for
 // known problematic values.
 func ValidateTLD(tld string) error {
+	if err := validateTLDLabel(tld); err != nil {
+		return err
+	}
+
+	// Advisory warnings
+	if tld == "local" && runtime.GOOS == "darwin" {
+		fmt.Fprintln(os.Stderr, "[WARN] '.local' is used by Bonjour/mDNS on macOS and may cause DNS resolution conflicts.")
+	}
+	if commonPublicTLDs[tld] {
+		fmt.Fprintf(os.Stderr, "[WARN] '.%s' is a real public TLD. Using it locally may break access to real websites on that TLD.\n", tld)
+	}
+
+	return nil
+}
+
+// validateTLDLabel checks tld without the advisory warnings, for validating
+// the configured TLD on every load.
+func validateTLDLabel(tld string) error {
 	if tld == "" {
 		return fmt.Errorf("TLD cannot be empty")
 	}
@@ -157,14 +267,5 @@ func ValidateTLD(tld string) error {
 	if tld == "localhost" {
 		return fmt.Errorf("TLD 'localhost' is reserved (RFC 6761) and cannot be used")
 	}
-
-	// Advisory warnings
-	if tld == "local" && runtime.GOOS == "darwin" {
-		fmt.Fprintln(os.Stderr, "[WARN] '.local' is used by Bonjour/mDNS on macOS and may cause DNS resolution conflicts.")
-	}
-	if commonPublicTLDs[tld] {
-		fmt.Fprintf(os.Stderr, "[WARN] '.%s' is a real public TLD. Using it locally may break access to real websites on that TLD.\n", tld)
-	}
-
 	return nil
 }
@@ -0,0 +1,363 @@
+// Package docker is a small client for the Docker Engine API. devinfra uses it
+// for read-only queries (containers, networks, events) so they don't fork the
+// docker CLI and scrape its output; orchestration stays with docker compose.
+package docker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Compose labels set on every container docker compose creates.
+const (
+	LabelProject = "com.docker.compose.project"
+	LabelService = "com.docker.compose.service"
+)
+
+// DefaultSocket is the Engine API socket used when neither DOCKER_HOST nor a
+// docker context names another.
+const DefaultSocket = "/var/run/docker.sock"
+
+// ErrNotFound is returned when the requested container or network does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrUnsupportedHost is returned for docker hosts the client can't reach,
+// such as ssh:// hosts.
+var ErrUnsupportedHost = errors.New("unsupported docker host")
+
+// Client talks to the Docker Engine API over a unix socket or TCP.
+type Client struct {
+	http *http.Client
+	base string // scheme and host prefixed to every request path
+}
+
+// NewClient returns a client for the host the docker CLI uses, as resolved by
+// CurrentHost. tcp:// hosts use TLS when DOCKER_TLS_VERIFY is set, with
+// certificates from DOCKER_CERT_PATH as the docker CLI does.
+func NewClient() (*Client, error) {
+	host, ok := CurrentHost()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedHost, host)
+	}
+	return NewClientForHost(host)
+}
+
+// NewClientForHost returns a client for an explicit unix:// or tcp:// host,
+// e.g. the Docker-compatible API socket of another runtime.
+func NewClientForHost(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing docker host %q: %w", host, err)
+	}
+
+	transport := &http.Transport{}
+	c := &Client{http: &http.Client{Transport: transport}}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		c.base = "http://docker"
+	case "tcp":
+		c.base = "http://" + u.Host
+		if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+			cfg, err := tlsConfig(os.Getenv("DOCKER_CERT_PATH"))
+			if err != nil {
+				return nil, err
+			}
+			transport.TLSClientConfig = cfg
+			c.base = "https://" + u.Host
+		}
+	default:
+		return nil, fmt.Errorf("%w %q: only unix:// and tcp:// are supported", ErrUnsupportedHost, host)
+	}
+	return c, nil
+}
+
+// LocalSocket returns the standard socket path, falling back to the per-user
+// socket Docker Desktop creates on macOS when the standard one is missing.
+func LocalSocket() string {
+	if _, err := os.Stat(DefaultSocket); err != nil && runtime.GOOS == "darwin" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".docker", "run", "docker.sock")
+		}
+	}
+	return DefaultSocket
+}
+
+func tlsConfig(certPath string) (*tls.Config, error) {
+	if certPath == "" {
+		home, _ := os.UserHomeDir()
+		certPath = filepath.Join(home, ".docker")
+	}
+	ca, err := os.ReadFile(filepath.Join(certPath, "ca.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("reading docker CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca)
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certPath, "cert.pem"), filepath.Join(certPath, "key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("loading docker client cert: %w", err)
+	}
+	return &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{cert}}, nil
+}
+
+// get issues a GET request and returns the response for a 2xx status.
+func (c *Client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Drop the request URL from the error; the dial error is what matters.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("cannot connect to docker: %w", err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var msg struct {
+		Message string `json:"message"`
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(body, &msg) != nil || msg.Message == "" {
+		msg.Message = strings.TrimSpace(string(body))
+	}
+	return nil, fmt.Errorf("docker API %s: %s: %s", path, resp.Status, msg.Message)
+}
+
+// getJSON issues a GET request and decodes the JSON response into v.
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	resp, err := c.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding docker API %s: %w", path, err)
+	}
+	return nil
+}
+
+// Ping checks that the Engine API is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.get(ctx, "/_ping", nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Container is an entry from the container list.
+type Container struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Labels map[string]string `json:"Labels"`
+	State  string            `json:"State"`  // running, exited, restarting, ...
+	Status string            `json:"Status"` // human-readable, e.g. "Up 2 minutes (healthy)"
+	Ports  []Port            `json:"Ports"`
+}
+
+// Port is a container port, published on the host if PublicPort is set.
+type Port struct {
+	IP          string `json:"IP"`
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort"`
+	Type        string `json:"Type"` // tcp or udp
+}
+
+// Name returns the container's primary name without the leading slash.
+func (c Container) Name() string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// ListOptions filters the container list.
+type ListOptions struct {
+	All    bool     // include stopped containers
+	Labels []string // "key" or "key=value"; all must match
+	Status []string // e.g. "running"
+}
+
+// Containers lists containers matching opts.
+func (c *Client) Containers(ctx context.Context, opts ListOptions) ([]Container, error) {
+	query := url.Values{}
+	if opts.All {
+		query.Set("all", "true")
+	}
+	filters := map[string][]string{}
+	if len(opts.Labels) > 0 {
+		filters["label"] = opts.Labels
+	}
+	if len(opts.Status) > 0 {
+		filters["status"] = opts.Status
+	}
+	if len(filters) > 0 {
+		data, _ := json.Marshal(filters)
+		query.Set("filters", string(data))
+	}
+
+	var containers []Container
+	if err := c.getJSON(ctx, "/containers/json", query, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// ContainerDetails is the subset of a container inspect response devinfra uses.
+type ContainerDetails struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	State struct {
+		Status   string `json:"Status"`
+		Running  bool   `json:"Running"`
+		ExitCode int    `json:"ExitCode"`
+		Health   *struct {
+			Status string `json:"Status"` // starting, healthy, unhealthy
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	NetworkSettings struct {
+		// Ports maps "80/tcp" to its host bindings.
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+// HealthStatus returns the container's healthcheck status, or "" if it has
+// no healthcheck.
+func (d *ContainerDetails) HealthStatus() string {
+	if d.State.Health == nil {
+		return ""
+	}
+	return d.State.Health.Status
+}
+
+// InspectContainer returns details for a container by name or ID. It returns
+// ErrNotFound if the container does not exist.
+func (c *Client) InspectContainer(ctx context.Context, name string) (*ContainerDetails, error) {
+	var d ContainerDetails
+	if err := c.getJSON(ctx, "/containers/"+url.PathEscape(name)+"/json", nil, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ContainerRunning reports whether the named container exists and is running.
+func (c *Client) ContainerRunning(ctx context.Context, name string) bool {
+	d, err := c.InspectContainer(ctx, name)
+	return err == nil && d.State.Running
+}
+
+// Network is the subset of a network inspect response devinfra uses.
+type Network struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Driver string `json:"Driver"`
+}
+
+// InspectNetwork returns a network by name or ID. It returns ErrNotFound if
+// the network does not exist.
+func (c *Client) InspectNetwork(ctx context.Context, name string) (*Network, error) {
+	var n Network
+	if err := c.getJSON(ctx, "/networks/"+url.PathEscape(name), nil, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// NetworkExists reports whether the named network exists.
+func (c *Client) NetworkExists(ctx context.Context, name string) bool {
+	_, err := c.InspectNetwork(ctx, name)
+	return err == nil
+}
+
+// Event is a message from the Engine API event stream.
+type Event struct {
+	Type   string `json:"Type"`   // container, network, ...
+	Action string `json:"Action"` // start, die, health_status: healthy, ...
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"` // includes container labels
+	} `json:"Actor"`
+	Time int64 `json:"time"`
+}
+
+// Events streams events matching the given labels from now until ctx is
+// cancelled or the connection drops. The events channel is closed when the
+// stream ends; a non-nil error is then sent on the error channel unless ctx
+// was cancelled.
+func (c *Client) Events(ctx context.Context, labels []string) (<-chan Event, <-chan error) {
+	events := make(chan Event)
+	errs := make(chan error, 1)
+
+	query := url.Values{}
+	query.Set("since", fmt.Sprint(time.Now().Unix()))
+	if len(labels) > 0 {
+		data, _ := json.Marshal(map[string][]string{"label": labels})
+		query.Set("filters", string(data))
+	}
+
+	go func() {
+		defer close(events)
+		resp, err := c.get(ctx, "/events", query)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var e Event
+			if err := dec.Decode(&e); err != nil {
+				if ctx.Err() == nil && !errors.Is(err, io.EOF) {
+					errs <- fmt.Errorf("docker events: %w", err)
+				}
+				return
+			}
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, errs
+}
@@ -0,0 +1,110 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// fakeEngine serves handler on a unix socket and points DOCKER_HOST at it.
+func fakeEngine(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listening on fake socket: %v", err)
+	}
+	srv := &http.Server{Handler: handler}
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	t.Setenv("DOCKER_HOST", "unix://"+socket)
+	t.Setenv("DOCKER_CONTEXT", "")
+	c, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func TestContainers(t *testing.T) {
+	var gotFilters map[string][]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.Unmarshal([]byte(r.URL.Query().Get("filters")), &gotFilters)
+		_, _ = w.Write([]byte(`[{"Id":"abc","Names":["/myapp-web-1"],"State":"running",
+			"Labels":{"com.docker.compose.project":"myapp","traefik.http.routers.x.rule":"Host(` + "`a`" + `, ` + "`b`" + `)"}}]`))
+	})
+	c := fakeEngine(t, mux)
+
+	containers, err := c.Containers(context.Background(), ListOptions{Labels: []string{LabelProject}, Status: []string{"running"}})
+	if err != nil {
+		t.Fatalf("Containers: %v", err)
+	}
+	if len(containers) != 1 || containers[0].Name() != "myapp-web-1" || containers[0].Labels[LabelProject] != "myapp" {
+		t.Errorf("Containers = %+v", containers)
+	}
+	if !slices.Equal(gotFilters["label"], []string{LabelProject}) || !slices.Equal(gotFilters["status"], []string{"running"}) {
+		t.Errorf("filters = %v", gotFilters)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/traefik/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id":"abc","Name":"/traefik","State":{"Status":"running","Running":true,"Health":{"Status":"healthy"}}}`))
+	})
+	mux.HandleFunc("GET /networks/traefik", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id":"n1","Name":"traefik","Driver":"bridge"}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"No such object"}`))
+	})
+	c := fakeEngine(t, mux)
+	ctx := context.Background()
+
+	d, err := c.InspectContainer(ctx, "traefik")
+	if err != nil {
+		t.Fatalf("InspectContainer: %v", err)
+	}
+	if !d.State.Running || d.HealthStatus() != "healthy" {
+		t.Errorf("InspectContainer = %+v", d.State)
+	}
+	if _, err := c.InspectContainer(ctx, "dnsmasq"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("InspectContainer(missing) error = %v, want ErrNotFound", err)
+	}
+	if c.ContainerRunning(ctx, "dnsmasq") {
+		t.Errorf("ContainerRunning(missing) = true")
+	}
+	if !c.NetworkExists(ctx, "traefik") || c.NetworkExists(ctx, "other") {
+		t.Errorf("NetworkExists gave wrong answers")
+	}
+}
+
+func TestEvents(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Type":"container","Action":"start"}` + "\n" + `{"Type":"container","Action":"health_status: healthy"}` + "\n"))
+	})
+	c := fakeEngine(t, mux)
+
+	events, errs := c.Events(context.Background(), []string{LabelProject + "=myapp"})
+	var actions []string
+	for e := range events {
+		actions = append(actions, e.Action)
+	}
+	if !slices.Equal(actions, []string{"start", "health_status: healthy"}) {
+		t.Errorf("actions = %v", actions)
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("Events error: %v", err)
+	default:
+	}
+}
@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CurrentHost returns the Engine API host the docker CLI would use:
+// DOCKER_HOST, else the docker endpoint of the current context (DOCKER_CONTEXT,
+// or currentContext in the CLI config), else the local socket. ok is false if
+// the endpoint is one this client can't reach, such as an ssh:// host or a
+// context with TLS certificates; callers then go through the docker CLI,
+// which can.
+func CurrentHost() (host string, ok bool) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" && os.Getenv("DOCKER_CONTEXT") == "" {
+		return host, supportedHost(host)
+	}
+	ep, ok := contextEndpoint(currentContext())
+	if !ok {
+		return "unix://" + LocalSocket(), true
+	}
+	return ep.Host, supportedHost(ep.Host) && !ep.tls
+}
+
+// supportedHost reports whether NewClientForHost accepts host.
+func supportedHost(host string) bool {
+	return strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "tcp://")
+}
+
+// configDir is the docker CLI's config directory.
+func configDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker")
+}
+
+// currentContext returns the name of the docker CLI's current context, or ""
+// for the default one.
+func currentContext() string {
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return name
+	}
+	data, err := os.ReadFile(filepath.Join(configDir(), "config.json"))
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	_ = json.Unmarshal(data, &cfg)
+	return cfg.CurrentContext
+}
+
+// endpoint is a context's docker endpoint.
+type endpoint struct {
+	Host string
+	tls  bool // the context stores TLS certificates for it
+}
+
+// contextEndpoint reads the docker endpoint of a named context from the CLI's
+// context store, where each context lives in a directory named by the
+// SHA-256 of its name. The default context has no entry.
+func contextEndpoint(name string) (endpoint, bool) {
+	if name == "" || name == "default" {
+		return endpoint{}, false
+	}
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+	data, err := os.ReadFile(filepath.Join(configDir(), "contexts", "meta", id, "meta.json"))
+	if err != nil {
+		return endpoint{}, false
+	}
+	var meta struct {
+		Endpoints map[string]struct {
+			Host string `json:"Host"`
+		} `json:"Endpoints"`
+	}
+	if json.Unmarshal(data, &meta) != nil || meta.Endpoints["docker"].Host == "" {
+		return endpoint{}, false
+	}
+	_, err = os.Stat(filepath.Join(configDir(), "contexts", "tls", id, "docker"))
+	return endpoint{Host: meta.Endpoints["docker"].Host, tls: err == nil}, true
+}
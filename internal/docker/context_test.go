@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeContext adds a context with the given docker endpoint to the CLI
+// config in dir.
+func writeContext(t *testing.T, dir, name, host string, tls bool) {
+	t.Helper()
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+	meta := filepath.Join(dir, "contexts", "meta", id)
+	if err := os.MkdirAll(meta, 0700); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"Name":"` + name + `","Metadata":{},"Endpoints":{"docker":{"Host":"` + host + `","SkipTLSVerify":false}}}`
+	if err := os.WriteFile(filepath.Join(meta, "meta.json"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if tls {
+		if err := os.MkdirAll(filepath.Join(dir, "contexts", "tls", id, "docker"), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCurrentHost(t *testing.T) {
+	dir := t.TempDir()
+	writeContext(t, dir, "colima", "unix:///home/me/.colima/default/docker.sock", false)
+	writeContext(t, dir, "remote", "ssh://me@build-box", false)
+	writeContext(t, dir, "secure", "tcp://10.0.0.5:2376", true)
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"currentContext":"colima"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		dockerHost string
+		context    string
+		wantHost   string
+		wantOK     bool
+	}{
+		{"current context from config", "", "", "unix:///home/me/.colima/default/docker.sock", true},
+		{"DOCKER_HOST", "tcp://127.0.0.1:2375", "", "tcp://127.0.0.1:2375", true},
+		{"DOCKER_CONTEXT over DOCKER_HOST", "tcp://127.0.0.1:2375", "colima", "unix:///home/me/.colima/default/docker.sock", true},
+		{"ssh context", "", "remote", "ssh://me@build-box", false},
+		{"context with TLS", "", "secure", "tcp://10.0.0.5:2376", false},
+		{"ssh DOCKER_HOST", "ssh://me@build-box", "", "ssh://me@build-box", false},
+		{"default context", "", "default", "unix://" + LocalSocket(), true},
+		{"unknown context", "", "gone", "unix://" + LocalSocket(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_CONFIG", dir)
+			t.Setenv("DOCKER_HOST", tt.dockerHost)
+			t.Setenv("DOCKER_CONTEXT", tt.context)
+			host, ok := CurrentHost()
+			if host != tt.wantHost || ok != tt.wantOK {
+				t.Errorf("CurrentHost = %q, %v, want %q, %v", host, ok, tt.wantHost, tt.wantOK)
+			}
+		})
+	}
+}
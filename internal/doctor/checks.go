@@ -3,12 +3,16 @@ package doctor
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/heysarver/devinfra/internal/compose"
 	"github.com/heysarver/devinfra/internal/config"
 	"github.com/heysarver/devinfra/internal/ui"
 )
@@ -37,22 +41,21 @@ func cmdExists(name string) bool {
 	return err == nil
 }
 
-func containerRunning(ctx context.Context, name string) bool {
-	cmd := exec.CommandContext(ctx, "docker", "inspect", "-f", "{{.State.Running}}", name)
-	out, err := cmd.Output()
-	if err != nil {
-		return false
+// runtimeRemediation suggests how to get the selected runtime running.
+func runtimeRemediation(name string) string {
+	switch name {
+	case "podman":
+		return "Install Podman and start its API socket (systemctl --user enable --now podman.socket, or 'podman machine start' on macOS), or check CONTAINER_HOST"
+	case "nerdctl":
+		return "Install nerdctl and make sure containerd is running"
+	default:
+		return "Install Docker and make sure it is running (https://docs.docker.com/get-docker/), or check DOCKER_HOST and 'docker context ls'"
 	}
-	return strings.TrimSpace(string(out)) == "true"
 }
 
-func networkExists(ctx context.Context, name string) bool {
-	cmd := exec.CommandContext(ctx, "docker", "network", "inspect", name)
-	return cmd.Run() == nil
-}
-
-// RunAll executes all health checks and returns a report.
-func RunAll(ctx context.Context) Report {
+// RunAll executes all health checks against the given settings and returns a
+// report.
+func RunAll(ctx context.Context, s *config.Settings) Report {
 	var mu sync.Mutex
 	var checks []CheckResult
 	var wg sync.WaitGroup
@@ -63,16 +66,20 @@ func RunAll(ctx context.Context) Report {
 		mu.Unlock()
 	}
 
+	rt := compose.RuntimeFor(s)
+
 	// Tool checks (parallel)
 	toolChecks := []struct {
 		name        string
 		fn          func() bool
 		remediation string
 	}{
-		{"Docker", func() bool {
-			cmd := exec.CommandContext(ctx, "docker", "info")
-			return cmd.Run() == nil
-		}, "Install Docker: https://docs.docker.com/get-docker/"},
+		{fmt.Sprintf("Runtime (%s)", rt.Name()), func() bool {
+			return rt.Ping(ctx) == nil
+		}, runtimeRemediation(rt.Name())},
+		{fmt.Sprintf("%s compose", rt.Binary()), func() bool {
+			return exec.CommandContext(ctx, rt.Binary(), "compose", "version").Run() == nil
+		}, fmt.Sprintf("Install compose support for %s (e.g. the Docker Compose v2 plugin, or podman-compose)", rt.Binary())},
 		{"mkcert", func() bool { return cmdExists("mkcert") },
 			"Install mkcert: brew install mkcert (macOS) or apt install mkcert (Ubuntu)"},
 		{"mkcert CA", func() bool {
@@ -100,9 +107,9 @@ func RunAll(ctx context.Context) Report {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		add(check(ctx, "Docker network", func() bool {
-			return networkExists(ctx, "traefik")
-		}, "Run 'di init' or 'docker network create traefik'"))
+		add(check(ctx, "Network", func() bool {
+			return rt.NetworkExists(ctx, "traefik")
+		}, fmt.Sprintf("Run 'di init' or '%s network create traefik'", rt.Binary())))
 	}()
 
 	// Container checks
@@ -113,16 +120,32 @@ func RunAll(ctx context.Context) Report {
 		go func() {
 			defer wg.Done()
 			add(check(ctx, fmt.Sprintf("%s container", c), func() bool {
-				return containerRunning(ctx, c)
+				return rt.ContainerRunning(ctx, c)
 			}, "Run 'di up' to start infrastructure"))
 		}()
 	}
 
+	// Traefik discovers routes through a Docker-compatible API socket
+	add(check(ctx, "Runtime API socket", func() bool {
+		return rt.Socket() != ""
+	}, fmt.Sprintf("%s has no Docker-compatible API for Traefik's Docker provider; set one with 'di config set runtime.socket <path>'", rt.Name())))
+
+	// Settings check
+	var invalid []string
+	for _, v := range s.Values {
+		if v.Error != "" {
+			invalid = append(invalid, v.Key)
+		}
+	}
+	add(check(ctx, "Configuration", func() bool {
+		return len(invalid) == 0
+	}, fmt.Sprintf("Invalid values for %s; run 'di config list' and fix them with 'di config set' or 'di config unset'", strings.Join(invalid, ", "))))
+
 	// Cert check
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		tld := config.TLD()
+		tld := s.TLD
 		add(check(ctx, "Infra certs", func() bool {
 			_, err := os.Stat(filepath.Join(config.CertsDir(), fmt.Sprintf("traefik.%s+1.pem", tld)))
 			return err == nil
@@ -133,9 +156,9 @@ func RunAll(ctx context.Context) Report {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		tld := config.TLD()
+		tld := s.TLD
 		add(check(ctx, "DNS resolution", func() bool {
-			port := config.DNSPort()
+			port := strconv.Itoa(s.DNSPort)
 			cmd := exec.CommandContext(ctx, "dig", "+short", tld+"."+tld, "@127.0.0.1", "-p", port)
 			out, err := cmd.Output()
 			if err != nil {
@@ -149,7 +172,7 @@ func RunAll(ctx context.Context) Report {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for _, c := range platformChecks(ctx) {
+		for _, c := range platformChecks(ctx, s) {
 			add(c)
 		}
 	}()
@@ -159,6 +182,7 @@ func RunAll(ctx context.Context) Report {
 	// Per-project checks (sequential since we load registry)
 	reg, err := config.LoadRegistry()
 	if err == nil && len(reg.Projects) > 0 {
+		traefikUp := rt.ContainerRunning(ctx, "traefik")
 		for _, p := range reg.Projects {
 			checks = append(checks, check(ctx, fmt.Sprintf("%s: directory", p.Name), func() bool {
 				_, err := os.Stat(p.Dir)
@@ -171,6 +195,20 @@ func RunAll(ctx context.Context) Report {
 				matches, _ := filepath.Glob(pattern)
 				return len(matches) > 0
 			}, fmt.Sprintf("Run 'di certs regen %s'", name)))
+
+			// Traefik routes host services to host.docker.internal, which
+			// only helps if the host answers there for listening ports
+			if !traefikUp {
+				continue
+			}
+			for _, svc := range p.HostServices() {
+				if !hostListening(svc.Port) {
+					continue
+				}
+				checks = append(checks, check(ctx, fmt.Sprintf("%s: Traefik reaches %s (:%d)", name, svc.Name, svc.Port), func() bool {
+					return traefikReachesHost(ctx, rt, svc.Port)
+				}, fmt.Sprintf("Make %s listen on 0.0.0.0 rather than only localhost, and allow the traefik network through the host firewall", svc.Name)))
+			}
 		}
 	}
 
@@ -189,6 +227,26 @@ func RunAll(ctx context.Context) Report {
 	}
 }
 
+// hostListening reports whether something accepts TCP connections on
+// localhost at port.
+func hostListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), 300*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// traefikReachesHost makes an HTTP request from inside the Traefik container
+// to port on the host, as Traefik does when routing a host service. Any HTTP
+// response counts, including errors; only failing to connect does not.
+func traefikReachesHost(ctx context.Context, rt compose.Runtime, port int) bool {
+	url := fmt.Sprintf("http://host.docker.internal:%d/", port)
+	out, err := exec.CommandContext(ctx, rt.Binary(), "exec", "traefik", "wget", "-q", "-O", "/dev/null", "-T", "3", url).CombinedOutput()
+	return err == nil || strings.Contains(string(out), "server returned error")
+}
+
 // PrintReport formats and prints the doctor report to stderr/stdout.
 func PrintReport(r Report) {
 	fmt.Fprintln(os.Stderr)
@@ -7,14 +7,15 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/heysarver/devinfra/internal/config"
 )
 
-func platformChecks(ctx context.Context) []CheckResult {
+func platformChecks(ctx context.Context, s *config.Settings) []CheckResult {
 	var checks []CheckResult
-	tld := config.TLD()
+	tld := s.TLD
 	resolverPath := fmt.Sprintf("/etc/resolver/%s", tld)
 
 	checks = append(checks, check(ctx, "Homebrew", func() bool {
@@ -35,7 +36,7 @@ func platformChecks(ctx context.Context) []CheckResult {
 		return strings.Contains(string(data), "nameserver 127.0.0.1")
 	}, "Run 'di init' to configure DNS resolver"))
 
-	port := config.DNSPort()
+	port := strconv.Itoa(s.DNSPort)
 	checks = append(checks, check(ctx, "Resolver port", func() bool {
 		data, err := os.ReadFile(resolverPath)
 		if err != nil {
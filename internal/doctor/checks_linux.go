@@ -4,13 +4,14 @@ package doctor
 
 import (
 	"context"
+	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/heysarver/devinfra/internal/config"
 )
 
-func platformChecks(ctx context.Context) []CheckResult {
+func platformChecks(ctx context.Context, s *config.Settings) []CheckResult {
 	var checks []CheckResult
 
 	checks = append(checks, check(ctx, "libnss3-tools", func() bool {
@@ -18,7 +19,7 @@ func platformChecks(ctx context.Context) []CheckResult {
 		return cmd.Run() == nil
 	}, "Install libnss3-tools: sudo apt install libnss3-tools"))
 
-	tld := config.TLD()
+	tld := s.TLD
 	checks = append(checks, check(ctx, "systemd-resolved ."+tld, func() bool {
 		cmd := exec.CommandContext(ctx, "resolvectl", "status")
 		out, err := cmd.Output()
@@ -28,6 +29,18 @@ func platformChecks(ctx context.Context) []CheckResult {
 		return strings.Contains(string(out), tld)
 	}, "Run 'di init' to configure systemd-resolved for ."+tld+" domains"))
 
+	// Linux engines only resolve host.docker.internal, where host-mode
+	// services are routed, through the compose file's host-gateway mapping
+	if s.Runtime != "podman" {
+		checks = append(checks, check(ctx, "Traefik host-gateway", func() bool {
+			data, err := os.ReadFile(config.ComposeFile())
+			return err == nil && strings.Contains(string(data), "host.docker.internal:host-gateway")
+		}, "Run 'di init' to update the infrastructure compose file, then 'di down && di up'"))
+	}
+
+	if s.Runtime != "docker" {
+		return checks
+	}
 	checks = append(checks, check(ctx, "Docker group", func() bool {
 		cmd := exec.CommandContext(ctx, "id", "-nG")
 		out, err := cmd.Output()
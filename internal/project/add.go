@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -15,11 +16,19 @@ import (
 
 // AddOpts contains the parameters for importing an existing project.
 type AddOpts struct {
-	Name        string
-	Dir         string
-	Services    []config.Service
-	ComposeFile string // detected compose filename (e.g., "compose.yaml")
-	Cloned      bool   // true if we cloned the directory (safe to remove on rollback)
+	Name             string
+	Dir              string
+	HostMode         bool
+	Services         []config.Service
+	Flavors          []string
+	ComposeFile      string   // detected compose filename (e.g., "compose.yaml")
+	ComposeOverrides []string // extra compose files declared in .devinfra.yaml
+	ExtraDomains     []string // additional wildcard domains, e.g. "*.myapp.example.dev"
+	DependsOn        []string // projects that must be running first
+	Profiles         []string // compose profiles enabled by default
+	Cloned           bool     // true if we cloned the directory (safe to remove on rollback)
+
+	Settings *config.Settings // configuration resolved for this invocation
 }
 
 // Add imports an existing project into devinfra management.
@@ -32,16 +41,45 @@ func Add(ctx context.Context, opts AddOpts) error {
 	defer func() { rb.execute() }()
 
 	dir := opts.Dir
+	s := opts.Settings
 
 	// If we cloned this directory, it's safe to remove on rollback
 	if opts.Cloned {
 		rb.add(func() error { return os.RemoveAll(dir) })
 	}
 
-	// Generate overlay if services were selected
-	if len(opts.Services) > 0 {
+	project := config.Project{
+		Name:             opts.Name,
+		Dir:              dir,
+		Domain:           fmt.Sprintf("*.%s.%s", opts.Name, s.TLD),
+		ExtraDomains:     opts.ExtraDomains,
+		HostMode:         opts.HostMode,
+		Services:         opts.Services,
+		Flavors:          opts.Flavors,
+		ComposeFile:      opts.ComposeFile,
+		ComposeOverrides: opts.ComposeOverrides,
+		DependsOn:        opts.DependsOn,
+		Profiles:         opts.Profiles,
+		Created:          time.Now().Format("2006-01-02"),
+	}
+	if len(project.Profiles) > 0 {
+		WarnUnknownProfiles(project, project.Profiles)
+	}
+
+	// Generate routing: file-provider config for host services, overlay for docker services
+	if len(project.HostServices()) > 0 {
+		ui.Info("Generating host-mode Traefik config...")
+		if err := generateHostConfig(project); err != nil {
+			return fmt.Errorf("generating host config: %w", err)
+		}
+		rb.add(func() error {
+			_ = os.Remove(hostConfigPath(opts.Name))
+			return nil
+		})
+	}
+	if needsOverlay(s, project) {
 		ui.Info("Generating docker-compose.devinfra.yaml...")
-		if err := generateOverlay(opts.Name, dir, opts.Services, config.Remote()); err != nil {
+		if err := generateOverlay(s, project); err != nil {
 			return fmt.Errorf("generating overlay: %w", err)
 		}
 		rb.add(func() error {
@@ -52,60 +90,56 @@ func Add(ctx context.Context, opts AddOpts) error {
 		ui.Info("Consider adding docker-compose.devinfra.yaml to your .gitignore")
 	}
 
+	// Render flavor overlays declared by the manifest that aren't committed
+	rendered, err := renderMissingFlavors(s, opts.Name, dir, opts.Flavors)
+	for _, path := range rendered {
+		rb.add(func() error {
+			_ = os.Remove(path)
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
 	// Generate certs
-	if err := compose.GenerateCerts(ctx, opts.Name); err != nil {
+	if err := compose.GenerateCerts(ctx, s, opts.Name, opts.ExtraDomains); err != nil {
 		return fmt.Errorf("generating certs: %w", err)
 	}
 	rb.add(func() error {
-		_ = compose.RemoveCerts(opts.Name)
+		_ = compose.RemoveCerts(s, opts.Name, opts.ExtraDomains)
 		return nil
 	})
 
 	// Register in projects.yaml
 	ui.Info("Registering project...")
-	reg, err := config.LoadRegistry()
-	if err != nil {
-		return fmt.Errorf("loading registry: %w", err)
-	}
-
-	project := config.Project{
-		Name:        opts.Name,
-		Dir:         dir,
-		Domain:      fmt.Sprintf("*.%s.%s", opts.Name, config.TLD()),
-		Services:    opts.Services,
-		ComposeFile: opts.ComposeFile,
-		Created:     time.Now().Format("2006-01-02"),
-	}
-
-	if err := reg.Add(project); err != nil {
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		if err := reg.Add(project); err != nil {
+			return err
+		}
+		warnUnregisteredDependencies(reg, project)
+		return nil
+	}); err != nil {
 		return err
 	}
-	if err := config.SaveRegistry(reg); err != nil {
-		return fmt.Errorf("saving registry: %w", err)
-	}
 	rb.add(func() error {
-		reg, _ := config.LoadRegistry()
-		if reg != nil {
-			_ = reg.Remove(opts.Name)
-			_ = config.SaveRegistry(reg)
-		}
+		_ = config.UpdateRegistry(func(reg *config.Registry) error {
+			return reg.Remove(opts.Name)
+		})
 		return nil
 	})
 
 	// Success — disarm rollback
 	rb.disarm()
 
-	tld := config.TLD()
+	tld := s.TLD
 	ui.Ok("Project '%s' registered!", opts.Name)
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintf(os.Stderr, "  Directory:  %s\n", dir)
 	if len(opts.Services) > 0 {
 		fmt.Fprintln(os.Stderr, "  URLs:")
-		for i, svc := range opts.Services {
-			if i == 0 {
-				fmt.Fprintf(os.Stderr, "    https://%s.%s\n", opts.Name, tld)
-			}
-			fmt.Fprintf(os.Stderr, "    https://%s.%s.%s\n", svc.Name, opts.Name, tld)
+		for _, u := range project.URLs() {
+			fmt.Fprintf(os.Stderr, "    %s\n", u)
 		}
 	}
 	fmt.Fprintf(os.Stderr, "  Dashboard:  https://traefik.%s\n", tld)
@@ -116,28 +150,85 @@ func Add(ctx context.Context, opts AddOpts) error {
 	return nil
 }
 
-// generateOverlay creates a docker-compose.devinfra.yaml with Traefik labels and networks.
-// When remote.Enabled, additional routers are generated for the remote domain.
-func generateOverlay(name, dir string, services []config.Service, remote config.RemoteConfig) error {
-	tld := config.TLD()
+// generateOverlay creates a docker-compose.devinfra.yaml with Traefik labels and
+// networks for the project's docker services. Each router matches the service's
+// subdomain under every base domain. When remote is enabled in s, additional
+// routers are generated for the remote domain. Services whose compose file already defines
+// routers are handled according to their Routing mode, and services in compose
+// profiles outside p.Profiles are left out.
+func generateOverlay(s *config.Settings, p config.Project) error {
+	remote := s.Remote
+
+	var detected map[string]compose.DetectedService
+	if len(p.DockerServices()) > 0 {
+		var err error
+		if detected, err = composeServices(p); err != nil {
+			for _, svc := range p.DockerServices() {
+				if svc.Routing == config.RoutingAdopt {
+					return fmt.Errorf("reading existing Traefik labels: %w", err)
+				}
+			}
+			ui.Warn("Could not read compose files for %s, labeling every service: %v", p.Name, err)
+		}
+	}
+	existing := existingRouters(detected)
+
+	// Services in profiles that aren't enabled don't run, so leave them out
+	var services []config.Service
+	for _, svc := range p.DockerServices() {
+		if compose.ProfileEnabled(detected[svc.Name].Profiles, p.Profiles) {
+			services = append(services, svc)
+		}
+	}
+
+	// A flavor service can share its name with a project service, such as
+	// valkey; its TCP router then goes on that service's entry, since a
+	// second entry under the same key is invalid YAML
+	tcpRoutes := make(map[string][]TCPRoute)
+	for _, r := range TCPRoutes(s, p) {
+		tcpRoutes[r.Service] = append(tcpRoutes[r.Service], r)
+	}
+
+	bases := p.BaseDomains()
 	var b strings.Builder
 
 	b.WriteString("# Generated by devinfra — do not edit manually\n")
 	b.WriteString("services:\n")
-	for i, svc := range services {
-		routerName := fmt.Sprintf("%s-%s", name, svc.Name)
-
-		var localRule string
-		if i == 0 {
-			localRule = fmt.Sprintf("Host(`%s.%s`) || Host(`%s.%s.%s`)", name, tld, svc.Name, name, tld)
-		} else {
-			localRule = fmt.Sprintf("Host(`%s.%s.%s`)", svc.Name, name, tld)
-		}
+	// SAN entries on the first remote router written trigger cert
+	// acquisition for the whole project
+	sansWritten := false
+	for _, svc := range services {
+		routerName := fmt.Sprintf("%s-%s", p.Name, svc.Name)
+		localRule := buildHostRule(p.ServiceHosts(svc, bases))
 
 		b.WriteString(fmt.Sprintf("  %s:\n", svc.Name))
 		b.WriteString("    networks:\n")
 		b.WriteString("      - traefik\n")
 		b.WriteString("    labels:\n")
+
+		tcp := tcpRoutes[svc.Name]
+		delete(tcpRoutes, svc.Name)
+		for _, r := range tcp {
+			writeTCPRouter(&b, p, r)
+		}
+
+		routers := existing[svc.Name]
+		if svc.Routing == config.RoutingAdopt && len(routers) == 0 {
+			ui.Warn("Service %s is set to adopt existing Traefik routers but its compose file defines none; generating one.", svc.Name)
+		}
+		switch {
+		case svc.Routing == config.RoutingSkip:
+			if len(tcp) > 0 {
+				b.WriteString("      - \"traefik.enable=true\"\n")
+			}
+			b.WriteString("      - \"traefik.docker.network=traefik\"\n\n")
+			continue
+		case svc.Routing == config.RoutingAdopt && len(routers) > 0:
+			writeAdoptedRouters(&b, p, svc, routers, remote, &sansWritten)
+			b.WriteString("\n")
+			continue
+		}
+
 		b.WriteString("      - \"traefik.enable=true\"\n")
 		b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.rule=%s\"\n", routerName, localRule))
 		b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.entrypoints=websecure\"\n", routerName))
@@ -147,19 +238,14 @@ func generateOverlay(name, dir string, services []config.Service, remote config.
 
 		if remote.Enabled {
 			remoteRouterName := routerName + "-remote"
-			var remoteRule string
-			if i == 0 {
-				remoteRule = fmt.Sprintf("Host(`%s.%s`) || Host(`%s.%s.%s`)", name, remote.Domain, svc.Name, name, remote.Domain)
-			} else {
-				remoteRule = fmt.Sprintf("Host(`%s.%s.%s`)", svc.Name, name, remote.Domain)
-			}
+			remoteRule := buildHostRule(p.ServiceHosts(svc, []string{fmt.Sprintf("%s.%s", p.Name, remote.Domain)}))
 			b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.rule=%s\"\n", remoteRouterName, remoteRule))
 			b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.entrypoints=websecure\"\n", remoteRouterName))
 			b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.tls.certresolver=cloudflare-acme\"\n", remoteRouterName))
-			if i == 0 {
-				// SAN entries on the first service router trigger cert acquisition for the whole project
+			if !sansWritten {
 				b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.tls.domains[0].main=*.%s\"\n", remoteRouterName, remote.Domain))
-				b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.tls.domains[1].main=*.%s.%s\"\n", remoteRouterName, name, remote.Domain))
+				b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.tls.domains[1].main=*.%s.%s\"\n", remoteRouterName, p.Name, remote.Domain))
+				sansWritten = true
 			}
 			b.WriteString(fmt.Sprintf("      - \"traefik.http.routers.%s.service=%s\"\n", remoteRouterName, routerName))
 		}
@@ -167,9 +253,167 @@ func generateOverlay(name, dir string, services []config.Service, remote config.
 		b.WriteString("\n")
 	}
 
+	// Flavor services clients reach over TCP, such as databases, are
+	// routed by TLS SNI on the entrypoint for their protocol
+	for _, r := range TCPRoutes(s, p) {
+		if _, ok := tcpRoutes[r.Service]; !ok {
+			continue // on a project service's entry above
+		}
+		b.WriteString(fmt.Sprintf("  %s:\n", r.Service))
+		b.WriteString("    networks:\n")
+		b.WriteString("      - traefik\n")
+		b.WriteString("    labels:\n")
+		b.WriteString("      - \"traefik.enable=true\"\n")
+		writeTCPRouter(&b, p, r)
+		b.WriteString("      - \"traefik.docker.network=traefik\"\n\n")
+	}
+
 	b.WriteString("networks:\n")
 	b.WriteString("  traefik:\n")
 	b.WriteString("    external: true\n")
 
-	return os.WriteFile(filepath.Join(dir, "docker-compose.devinfra.yaml"), []byte(b.String()), 0644)
+	return os.WriteFile(filepath.Join(p.Dir, "docker-compose.devinfra.yaml"), []byte(b.String()), 0644)
+}
+
+// writeTCPRouter writes the labels of a flavor service's TCP router.
+func writeTCPRouter(b *strings.Builder, p config.Project, r TCPRoute) {
+	routerName := fmt.Sprintf("%s-%s-tcp", p.Name, r.Service)
+	b.WriteString(fmt.Sprintf("      - \"traefik.tcp.routers.%s.rule=%s\"\n", routerName, buildSNIRule(append([]string{r.Host}, r.Aliases...))))
+	b.WriteString(fmt.Sprintf("      - \"traefik.tcp.routers.%s.entrypoints=%s\"\n", routerName, r.Entrypoint))
+	b.WriteString(fmt.Sprintf("      - \"traefik.tcp.routers.%s.tls=true\"\n", routerName))
+	b.WriteString(fmt.Sprintf("      - \"traefik.tcp.services.%s.loadbalancer.server.port=%d\"\n", routerName, r.Target))
+}
+
+// existingRouter is a Traefik router defined by a project's own compose labels.
+type existingRouter struct {
+	Name         string
+	Rule         string
+	Service      string // explicit service, if any
+	Middlewares  string
+	CertResolver string // ACME resolver, which devinfra's Traefik doesn't define
+}
+
+// composeServices reads the services of the project's own compose files (not
+// the devinfra or flavor overlays), keyed by name.
+func composeServices(p config.Project) (map[string]compose.DetectedService, error) {
+	files := append([]string{baseComposeFile(p)}, p.ComposeOverrides...)
+	detected, err := compose.ParseServices(p.Dir, files)
+	if err != nil {
+		return nil, err
+	}
+	services := make(map[string]compose.DetectedService, len(detected))
+	for _, d := range detected {
+		services[d.Name] = d
+	}
+	return services, nil
+}
+
+// existingRouters collects the routers each service defines in its labels.
+func existingRouters(services map[string]compose.DetectedService) map[string][]existingRouter {
+	routers := make(map[string][]existingRouter)
+	for _, d := range services {
+		for _, name := range d.Traefik().Routers {
+			prefix := "traefik.http.routers." + name + "."
+			routers[d.Name] = append(routers[d.Name], existingRouter{
+				Name:         name,
+				Rule:         d.Labels[prefix+"rule"],
+				Service:      d.Labels[prefix+"service"],
+				Middlewares:  d.Labels[prefix+"middlewares"],
+				CertResolver: d.Labels[prefix+"tls.certresolver"],
+			})
+		}
+	}
+	return routers
+}
+
+// writeAdoptedRouters overrides the host rules of a service's existing routers
+// so they answer on the devinfra domains over websecure, and adds remote
+// twins of them when remote is enabled. The project's SAN entries go on the
+// first remote twin unless *sansWritten says another router already has them.
+// A router's own cert resolver is cleared, since devinfra's Traefik doesn't
+// define it and serves the mkcert certificates instead.
+func writeAdoptedRouters(b *strings.Builder, p config.Project, svc config.Service, routers []existingRouter, remote config.RemoteConfig, sansWritten *bool) {
+	// Adopted rules may quote their arguments with double quotes
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	label := func(format string, args ...any) {
+		b.WriteString(fmt.Sprintf("      - \"%s\"\n", escape.Replace(fmt.Sprintf(format, args...))))
+	}
+
+	hosts := p.ServiceHosts(svc, p.BaseDomains())
+	label("traefik.enable=true")
+	for _, r := range routers {
+		label("traefik.http.routers.%s.rule=%s", r.Name, adoptRule(r.Rule, hosts))
+		label("traefik.http.routers.%s.entrypoints=websecure", r.Name)
+		label("traefik.http.routers.%s.tls=true", r.Name)
+		if r.CertResolver != "" {
+			label("traefik.http.routers.%s.tls.certresolver=", r.Name)
+		}
+	}
+	label("traefik.docker.network=traefik")
+
+	if !remote.Enabled {
+		return
+	}
+	remoteHosts := p.ServiceHosts(svc, []string{fmt.Sprintf("%s.%s", p.Name, remote.Domain)})
+	for _, r := range routers {
+		name := r.Name + "-remote"
+		label("traefik.http.routers.%s.rule=%s", name, adoptRule(r.Rule, remoteHosts))
+		label("traefik.http.routers.%s.entrypoints=websecure", name)
+		label("traefik.http.routers.%s.tls.certresolver=cloudflare-acme", name)
+		if !*sansWritten {
+			label("traefik.http.routers.%s.tls.domains[0].main=*.%s", name, remote.Domain)
+			label("traefik.http.routers.%s.tls.domains[1].main=*.%s.%s", name, p.Name, remote.Domain)
+			*sansWritten = true
+		}
+		if r.Service != "" {
+			label("traefik.http.routers.%s.service=%s", name, r.Service)
+		}
+		if r.Middlewares != "" {
+			label("traefik.http.routers.%s.middlewares=%s", name, r.Middlewares)
+		}
+	}
+}
+
+// hostMatcher matches the host matchers in a Traefik rule, along with any
+// other host matchers they are or-ed with. Arguments are quoted with
+// backticks or double quotes and may contain parentheses, as HostRegexp
+// groups do.
+var hostMatcher = func() *regexp.Regexp {
+	matcher := `Host(?:Regexp)?\((?:` + "`[^`]*`" + `|"(?:[^"\\]|\\.)*"|[^)` + "`" + `"])*\)`
+	return regexp.MustCompile(matcher + `(?:\s*\|\|\s*` + matcher + `)*`)
+}()
+
+// adoptRule rewrites the host matchers of an existing router rule to hosts,
+// keeping its other matchers such as PathPrefix. A rule without a host
+// matcher is restricted to hosts.
+func adoptRule(rule string, hosts []string) string {
+	local := buildHostRule(hosts)
+	if len(hosts) > 1 {
+		local = "(" + local + ")"
+	}
+	if strings.TrimSpace(rule) == "" {
+		return buildHostRule(hosts)
+	}
+	if !hostMatcher.MatchString(rule) {
+		return local + " && (" + rule + ")"
+	}
+	return hostMatcher.ReplaceAllLiteralString(rule, local)
+}
+
+// buildHostRule joins hostnames into a Traefik Host rule.
+func buildHostRule(hosts []string) string {
+	parts := make([]string, len(hosts))
+	for i, h := range hosts {
+		parts[i] = fmt.Sprintf("Host(`%s`)", h)
+	}
+	return strings.Join(parts, " || ")
+}
+
+// buildSNIRule builds a TCP router rule matching any of the TLS SNI hosts.
+func buildSNIRule(hosts []string) string {
+	parts := make([]string, len(hosts))
+	for i, h := range hosts {
+		parts[i] = fmt.Sprintf("HostSNI(`%s`)", h)
+	}
+	return strings.Join(parts, " || ")
 }
@@ -0,0 +1,145 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAdoptRule(t *testing.T) {
+	one := []string{"api.myapp.test"}
+	two := []string{"api.myapp.test", "api.myapp.example.dev"}
+
+	tests := []struct {
+		name  string
+		rule  string
+		hosts []string
+		want  string
+	}{
+		{"or-ed hosts", "Host(`a.example.com`) || Host(`b.example.com`)", one,
+			"Host(`api.myapp.test`)"},
+		{"or-ed hosts, several domains", "Host(`a.example.com`) || Host(`b.example.com`)", two,
+			"(Host(`api.myapp.test`) || Host(`api.myapp.example.dev`))"},
+		{"host and path", "Host(`x.example.com`) && PathPrefix(`/api`)", two,
+			"(Host(`api.myapp.test`) || Host(`api.myapp.example.dev`)) && PathPrefix(`/api`)"},
+		{"no host matcher", "PathPrefix(`/api`)", one,
+			"Host(`api.myapp.test`) && (PathPrefix(`/api`))"},
+		{"empty rule", "", two,
+			"Host(`api.myapp.test`) || Host(`api.myapp.example.dev`)"},
+		{"host regexp with groups", "HostRegexp(`^(api|www)\\.example\\.(com|dev)$`) && PathPrefix(`/v1`)", one,
+			"Host(`api.myapp.test`) && PathPrefix(`/v1`)"},
+		{"double-quoted host regexp", `HostRegexp("^(a|b)\\.example\\.com$") || Host("c.example.com")`, one,
+			"Host(`api.myapp.test`)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adoptRule(tt.rule, tt.hosts); got != tt.want {
+				t.Errorf("adoptRule(%q) = %q, want %q", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExistingRouters(t *testing.T) {
+	services := map[string]compose.DetectedService{
+		"web": {Name: "web", Labels: map[string]string{
+			"traefik.enable":                                     "true",
+			"traefik.http.routers.web.rule":                      "Host(`web.example.com`)",
+			"traefik.http.routers.web.tls.certresolver":          "letsencrypt",
+			"traefik.http.routers.web-api.rule":                  "Host(`web.example.com`) && PathPrefix(`/api`)",
+			"traefik.http.routers.web-api.service":               "api",
+			"traefik.http.routers.web-api.middlewares":           "strip",
+			"traefik.http.services.web.loadbalancer.server.port": "3000",
+		}},
+		"db": {Name: "db"},
+	}
+
+	got := existingRouters(services)
+	want := []existingRouter{
+		{Name: "web", Rule: "Host(`web.example.com`)", CertResolver: "letsencrypt"},
+		{Name: "web-api", Rule: "Host(`web.example.com`) && PathPrefix(`/api`)", Service: "api", Middlewares: "strip"},
+	}
+	if !slices.Equal(got["web"], want) {
+		t.Errorf("existingRouters[web] = %+v, want %+v", got["web"], want)
+	}
+	if len(got["db"]) != 0 {
+		t.Errorf("existingRouters[db] = %+v, want none", got["db"])
+	}
+}
+
+func TestWriteAdoptedRouters(t *testing.T) {
+	p := config.Project{Name: "myapp", Domain: "*.myapp.test"}
+	svc := config.Service{Name: "web", Port: 3000}
+	routers := []existingRouter{
+		{Name: "web", Rule: `Host("web.example.com") && PathPrefix("/api")`, CertResolver: "letsencrypt"},
+		{Name: "web-docs", Rule: "Host(`docs.example.com`)"},
+	}
+
+	var b strings.Builder
+	sansWritten := false
+	writeAdoptedRouters(&b, p, svc, routers, config.RemoteConfig{}, &sansWritten)
+	got := b.String()
+
+	for _, want := range []string{
+		`      - "traefik.http.routers.web.rule=Host(` + "`web.myapp.test`" + `) && PathPrefix(\"/api\")"` + "\n",
+		`      - "traefik.http.routers.web.tls.certresolver="` + "\n",
+		`      - "traefik.http.routers.web-docs.rule=Host(` + "`web.myapp.test`" + `)"` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("labels missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "web-docs.tls.certresolver") {
+		t.Errorf("cleared the cert resolver of a router without one:\n%s", got)
+	}
+}
+
+func TestGenerateOverlaySharedServiceName(t *testing.T) {
+	dir := t.TempDir()
+	base := "services:\n  web:\n    image: nginx\n  valkey:\n    image: valkey/valkey\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yaml"), []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := config.Project{
+		Name:     "myapp",
+		Dir:      dir,
+		Domain:   "*.myapp.test",
+		Services: []config.Service{{Name: "web", Port: 3000}, {Name: "valkey", Port: 8001}},
+		Flavors:  []string{"redis", "postgres"},
+	}
+	if err := generateOverlay(&config.Settings{TCPRouting: true}, p); err != nil {
+		t.Fatalf("generateOverlay: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "docker-compose.devinfra.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var overlay struct {
+		Services map[string]struct {
+			Labels []string `yaml:"labels"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		t.Fatalf("overlay is not valid YAML: %v\n%s", err, data)
+	}
+	if got := len(overlay.Services); got != 3 {
+		t.Errorf("overlay has %d services, want web, valkey and postgres:\n%s", got, data)
+	}
+	labels := overlay.Services["valkey"].Labels
+	for _, want := range []string{
+		"traefik.http.routers.myapp-valkey.entrypoints=websecure",
+		"traefik.tcp.routers.myapp-valkey-tcp.entrypoints=redis",
+	} {
+		if !slices.Contains(labels, want) {
+			t.Errorf("valkey labels %v missing %q", labels, want)
+		}
+	}
+}
@@ -30,6 +30,8 @@ type CreateOpts struct {
 	Flavors     []string
 	Preset      string   // e.g., "wordpress" — overrides image and compose generation
 	Scaffolding []string // directories to create with .gitkeep (e.g., "wp-content/themes/")
+
+	Settings *config.Settings // configuration resolved for this invocation
 }
 
 type templateData struct {
@@ -69,7 +71,7 @@ func Create(ctx context.Context, opts CreateOpts) error {
 	// Prepare template data
 	data := templateData{
 		ProjectName:      opts.Name,
-		TLD:              config.TLD(),
+		TLD:              opts.Settings.TLD,
 		PostgresPassword: randomPassword(24),
 		RabbitmqPassword: randomPassword(24),
 		MinioPassword:    randomPassword(24),
@@ -87,14 +89,24 @@ func Create(ctx context.Context, opts CreateOpts) error {
 		opts.Services = entrypointServices(opts.Flavors)
 	}
 
+	project := config.Project{
+		Name:     opts.Name,
+		Dir:      dir,
+		Domain:   fmt.Sprintf("*.%s.%s", opts.Name, opts.Settings.TLD),
+		HostMode: opts.HostMode,
+		Services: opts.Services,
+		Flavors:  opts.Flavors,
+		Created:  time.Now().Format("2006-01-02"),
+	}
+
 	// Generate docker-compose or host config
 	if opts.HostMode {
 		ui.Info("Generating host-mode Traefik config...")
-		if err := generateHostConfig(opts.Name, dir, opts.Services); err != nil {
+		if err := generateHostConfig(project); err != nil {
 			return fmt.Errorf("generating host config: %w", err)
 		}
 		rb.add(func() error {
-			_ = os.Remove(filepath.Join(config.DynamicDir(), fmt.Sprintf("host-%s.yaml", opts.Name)))
+			_ = os.Remove(hostConfigPath(opts.Name))
 			return nil
 		})
 	} else if opts.Preset != "" {
@@ -114,7 +126,7 @@ func Create(ctx context.Context, opts CreateOpts) error {
 		}
 	} else {
 		ui.Info("Generating docker-compose.yaml...")
-		if err := generateDockerCompose(opts.Name, dir, opts.Services); err != nil {
+		if err := generateDockerCompose(project); err != nil {
 			return fmt.Errorf("generating compose: %w", err)
 		}
 	}
@@ -134,11 +146,11 @@ func Create(ctx context.Context, opts CreateOpts) error {
 	// Generate README
 	switch {
 	case opts.Preset == "wordpress" || hasFlavorInList(opts.Flavors, "wordpress"):
-		generateWordPressReadme(opts.Name, dir)
+		generateWordPressReadme(opts.Name, dir, opts.Settings.TLD)
 	case opts.Preset == "ghost" || hasFlavorInList(opts.Flavors, "ghost"):
-		generateGhostReadme(opts.Name, dir)
+		generateGhostReadme(opts.Name, dir, opts.Settings.TLD)
 	default:
-		generateReadme(opts.Name, dir, opts.Services)
+		generateReadme(project)
 	}
 
 	// Render flavor overlays
@@ -154,60 +166,47 @@ func Create(ctx context.Context, opts CreateOpts) error {
 		}
 	}
 
+	// Route flavor databases and brokers through Traefik's TCP entrypoints
+	if len(TCPRoutes(opts.Settings, project)) > 0 {
+		if err := generateOverlay(opts.Settings, project); err != nil {
+			return fmt.Errorf("generating overlay: %w", err)
+		}
+	}
+
 	// Generate certs
-	if err := compose.GenerateCerts(ctx, opts.Name); err != nil {
+	if err := compose.GenerateCerts(ctx, opts.Settings, opts.Name, nil); err != nil {
 		return fmt.Errorf("generating certs: %w", err)
 	}
 	rb.add(func() error {
-		_ = compose.RemoveCerts(opts.Name)
+		_ = compose.RemoveCerts(opts.Settings, opts.Name, nil)
 		return nil
 	})
 
 	// Register in projects.yaml
 	ui.Info("Registering project...")
-	reg, err := config.LoadRegistry()
-	if err != nil {
-		return fmt.Errorf("loading registry: %w", err)
-	}
-
-	project := config.Project{
-		Name:     opts.Name,
-		Dir:      dir,
-		Domain:   fmt.Sprintf("*.%s.%s", opts.Name, config.TLD()),
-		HostMode: opts.HostMode,
-		Services: opts.Services,
-		Flavors:  opts.Flavors,
-		Created:  time.Now().Format("2006-01-02"),
-	}
 
-	if err := reg.Add(project); err != nil {
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		return reg.Add(project)
+	}); err != nil {
 		return err
 	}
-	if err := config.SaveRegistry(reg); err != nil {
-		return fmt.Errorf("saving registry: %w", err)
-	}
 	rb.add(func() error {
-		reg, _ := config.LoadRegistry()
-		if reg != nil {
-			_ = reg.Remove(opts.Name)
-			_ = config.SaveRegistry(reg)
-		}
+		_ = config.UpdateRegistry(func(reg *config.Registry) error {
+			return reg.Remove(opts.Name)
+		})
 		return nil
 	})
 
 	// Success — disarm rollback
 	rb.disarm()
 
-	tld := config.TLD()
+	tld := opts.Settings.TLD
 	ui.Ok("Project '%s' created!", opts.Name)
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintf(os.Stderr, "  Directory:  %s\n", dir)
 	fmt.Fprintln(os.Stderr, "  URLs:")
-	for i, svc := range opts.Services {
-		if i == 0 {
-			fmt.Fprintf(os.Stderr, "    https://%s.%s\n", opts.Name, tld)
-		}
-		fmt.Fprintf(os.Stderr, "    https://%s.%s.%s\n", svc.Name, opts.Name, tld)
+	for _, u := range project.URLs() {
+		fmt.Fprintf(os.Stderr, "    %s\n", u)
 	}
 	fmt.Fprintf(os.Stderr, "  Dashboard:  https://traefik.%s\n", tld)
 	fmt.Fprintln(os.Stderr)
@@ -304,26 +303,20 @@ func renderFlavor(dir, flavor string, data templateData) error {
 	return tmpl.Execute(f, data)
 }
 
-func generateDockerCompose(name, dir string, services []config.Service) error {
-	outPath := filepath.Join(dir, "docker-compose.yaml")
+func generateDockerCompose(p config.Project) error {
+	outPath := filepath.Join(p.Dir, "docker-compose.yaml")
 	if _, err := os.Stat(outPath); err == nil {
 		ui.Info("Skipping existing file: docker-compose.yaml")
 		return nil
 	}
 
-	tld := config.TLD()
+	bases := p.BaseDomains()
 	var b strings.Builder
 
 	b.WriteString("services:\n")
-	for i, svc := range services {
-		routerName := fmt.Sprintf("%s-%s", name, svc.Name)
-
-		var rule string
-		if i == 0 {
-			rule = fmt.Sprintf("Host(`%s.%s`) || Host(`%s.%s.%s`)", name, tld, svc.Name, name, tld)
-		} else {
-			rule = fmt.Sprintf("Host(`%s.%s.%s`)", svc.Name, name, tld)
-		}
+	for _, svc := range p.DockerServices() {
+		routerName := fmt.Sprintf("%s-%s", p.Name, svc.Name)
+		rule := buildHostRule(p.ServiceHosts(svc, bases))
 
 		b.WriteString(fmt.Sprintf("  %s:\n", svc.Name))
 		b.WriteString("    image: nginx:alpine\n")
@@ -344,26 +337,32 @@ func generateDockerCompose(name, dir string, services []config.Service) error {
 	b.WriteString("  traefik:\n")
 	b.WriteString("    external: true\n")
 	b.WriteString("  default:\n")
-	b.WriteString(fmt.Sprintf("    name: %s\n", name))
+	b.WriteString(fmt.Sprintf("    name: %s\n", p.Name))
 
 	return os.WriteFile(outPath, []byte(b.String()), 0644)
 }
 
-func generateHostConfig(name, dir string, services []config.Service) error {
-	tld := config.TLD()
+// generateHostConfig writes the Traefik file-provider config routing the
+// project's host services to host.docker.internal, removing it when the
+// project has none. Host-mode projects also get a network-only compose file.
+func generateHostConfig(p config.Project) error {
+	services := p.HostServices()
+	if len(services) == 0 {
+		if err := os.Remove(hostConfigPath(p.Name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	bases := p.BaseDomains()
 	var b strings.Builder
 
 	// Traefik file-provider config
 	b.WriteString("http:\n")
 	b.WriteString("  routers:\n")
-	for i, svc := range services {
-		routerName := fmt.Sprintf("%s-%s", name, svc.Name)
-		var rule string
-		if i == 0 {
-			rule = fmt.Sprintf("Host(`%s.%s`) || Host(`%s.%s.%s`)", name, tld, svc.Name, name, tld)
-		} else {
-			rule = fmt.Sprintf("Host(`%s.%s.%s`)", svc.Name, name, tld)
-		}
+	for _, svc := range services {
+		routerName := fmt.Sprintf("%s-%s", p.Name, svc.Name)
+		rule := buildHostRule(p.ServiceHosts(svc, bases))
 		b.WriteString(fmt.Sprintf("    %s:\n", routerName))
 		b.WriteString(fmt.Sprintf("      rule: \"%s\"\n", rule))
 		b.WriteString("      entryPoints:\n")
@@ -375,21 +374,23 @@ func generateHostConfig(name, dir string, services []config.Service) error {
 	b.WriteString("\n")
 	b.WriteString("  services:\n")
 	for _, svc := range services {
-		routerName := fmt.Sprintf("%s-%s", name, svc.Name)
+		routerName := fmt.Sprintf("%s-%s", p.Name, svc.Name)
 		b.WriteString(fmt.Sprintf("    %s:\n", routerName))
 		b.WriteString("      loadBalancer:\n")
 		b.WriteString("        servers:\n")
 		b.WriteString(fmt.Sprintf("          - url: \"http://host.docker.internal:%d\"\n", svc.Port))
 	}
 
-	hostConfigPath := filepath.Join(config.DynamicDir(), fmt.Sprintf("host-%s.yaml", name))
-	if err := os.WriteFile(hostConfigPath, []byte(b.String()), 0644); err != nil {
+	if err := os.WriteFile(hostConfigPath(p.Name), []byte(b.String()), 0644); err != nil {
 		return err
 	}
+	if !p.HostMode {
+		return nil
+	}
 
 	// Host-mode compose: just the network
-	composePath := filepath.Join(dir, "docker-compose.yaml")
-	if skipped, err := writeFileIfNotExists(composePath, []byte(fmt.Sprintf("networks:\n  default:\n    name: %s\n", name)), 0644); skipped {
+	composePath := filepath.Join(p.Dir, "docker-compose.yaml")
+	if skipped, err := writeFileIfNotExists(composePath, []byte(fmt.Sprintf("networks:\n  default:\n    name: %s\n", p.Name)), 0644); skipped {
 		ui.Info("Skipping existing file: docker-compose.yaml")
 		return nil
 	} else {
@@ -397,17 +398,21 @@ func generateHostConfig(name, dir string, services []config.Service) error {
 	}
 }
 
-func generateReadme(name, dir string, services []config.Service) {
-	outPath := filepath.Join(dir, "README.md")
+// hostConfigPath returns the file-provider config path for a project's host services.
+func hostConfigPath(name string) string {
+	return filepath.Join(config.DynamicDir(), fmt.Sprintf("host-%s.yaml", name))
+}
+
+func generateReadme(p config.Project) {
+	outPath := filepath.Join(p.Dir, "README.md")
 	if _, err := os.Stat(outPath); err == nil {
 		ui.Info("Skipping existing file: README.md")
 		return
 	}
 
-	tld := config.TLD()
 	var b strings.Builder
 
-	b.WriteString(fmt.Sprintf("# %s\n\n", name))
+	b.WriteString(fmt.Sprintf("# %s\n\n", p.Name))
 	b.WriteString("## Quick Start\n\n")
 	b.WriteString("```bash\n")
 	b.WriteString("make up      # Start project\n")
@@ -416,11 +421,8 @@ func generateReadme(name, dir string, services []config.Service) {
 	b.WriteString("make ps      # Show containers\n")
 	b.WriteString("```\n\n")
 	b.WriteString("## URLs\n\n")
-	for i, svc := range services {
-		if i == 0 {
-			b.WriteString(fmt.Sprintf("- https://%s.%s\n", name, tld))
-		}
-		b.WriteString(fmt.Sprintf("- https://%s.%s.%s\n", svc.Name, name, tld))
+	for _, u := range p.URLs() {
+		b.WriteString(fmt.Sprintf("- %s\n", u))
 	}
 	b.WriteString("\n## Infrastructure\n\n")
 	b.WriteString("This project uses [devinfra](https://github.com/heysarver/devinfra) for local development infrastructure.\n\n")
@@ -459,14 +461,13 @@ func renderPresetCompose(dir, preset string, data templateData) error {
 	return tmpl.Execute(f, data)
 }
 
-func generateWordPressReadme(name, dir string) {
+func generateWordPressReadme(name, dir, tld string) {
 	outPath := filepath.Join(dir, "README.md")
 	if _, err := os.Stat(outPath); err == nil {
 		ui.Info("Skipping existing file: README.md")
 		return
 	}
 
-	tld := config.TLD()
 	var b strings.Builder
 
 	b.WriteString(fmt.Sprintf("# %s\n\n", name))
@@ -497,14 +498,13 @@ func generateWordPressReadme(name, dir string) {
 	_ = os.WriteFile(outPath, []byte(b.String()), 0644)
 }
 
-func generateGhostReadme(name, dir string) {
+func generateGhostReadme(name, dir, tld string) {
 	outPath := filepath.Join(dir, "README.md")
 	if _, err := os.Stat(outPath); err == nil {
 		ui.Info("Skipping existing file: README.md")
 		return
 	}
 
-	tld := config.TLD()
 	var b strings.Builder
 
 	b.WriteString(fmt.Sprintf("# %s\n\n", name))
@@ -0,0 +1,68 @@
+package project
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/ui"
+)
+
+// AddDependencies records that name depends on deps, so they are started
+// first by 'di up'. Each dependency must be registered, and the change is
+// refused if it would create a cycle.
+func AddDependencies(name string, deps []string) error {
+	var added []string
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		p := reg.Get(name)
+		if p == nil {
+			return fmt.Errorf("project %q not found in registry", name)
+		}
+		for _, dep := range deps {
+			if dep == name {
+				return fmt.Errorf("project %q cannot depend on itself", name)
+			}
+			if reg.Get(dep) == nil {
+				return fmt.Errorf("project %q not found in registry", dep)
+			}
+			if !slices.Contains(p.DependsOn, dep) {
+				p.DependsOn = append(p.DependsOn, dep)
+				added = append(added, dep)
+			}
+		}
+		return reg.CheckCycles()
+	}); err != nil {
+		return err
+	}
+
+	if len(added) == 0 {
+		ui.Info("Project '%s' already depends on those projects.", name)
+		return nil
+	}
+	ui.Ok("Project '%s' now depends on: %v", name, added)
+	return nil
+}
+
+// RemoveDependencies drops deps from name's depends_on.
+func RemoveDependencies(name string, deps []string) error {
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		p := reg.Get(name)
+		if p == nil {
+			return fmt.Errorf("project %q not found in registry", name)
+		}
+		for _, dep := range deps {
+			if !slices.Contains(p.DependsOn, dep) {
+				return fmt.Errorf("project %q does not depend on %q", name, dep)
+			}
+		}
+		p.DependsOn = slices.DeleteFunc(p.DependsOn, func(d string) bool {
+			return slices.Contains(deps, d)
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	ui.Ok("Removed dependencies from '%s': %v", name, deps)
+	return nil
+}
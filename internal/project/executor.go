@@ -0,0 +1,143 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/ui"
+)
+
+// DefaultParallel is how many projects are started or stopped at once.
+const DefaultParallel = 4
+
+// Result statuses.
+const (
+	ResultOK      = "ok"
+	ResultFailed  = "failed"
+	ResultSkipped = "skipped" // a task it waited on failed, or the run was canceled
+)
+
+// Task is one project operation run by an Executor.
+type Task struct {
+	Name  string   // project name, shown as the output prefix
+	After []string // tasks that must finish first; names not in the run are ignored
+	Run   func(ctx context.Context, out io.Writer) error
+}
+
+// Result is the outcome of a task.
+type Result struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Executor runs project tasks concurrently, at most Parallel at a time, and
+// starts each task only after the tasks it comes after have finished. Each
+// task's output, including that of the compose commands it runs, is
+// prefixed with its name.
+type Executor struct {
+	Parallel int
+	// KeepGoing runs a task even if one it comes after failed, as when
+	// stopping projects. Otherwise the task is skipped.
+	KeepGoing bool
+}
+
+// Run executes tasks and returns their results in task order. Tasks must
+// not come after each other in a cycle.
+func (e Executor) Run(ctx context.Context, tasks []Task) []Result {
+	index := make(map[string]int, len(tasks))
+	width := 0
+	for i, t := range tasks {
+		index[t.Name] = i
+		width = max(width, len(t.Name))
+	}
+
+	results := make([]Result, len(tasks))
+	done := make([]chan struct{}, len(tasks))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	slots := make(chan struct{}, max(1, e.Parallel))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, t := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			results[i] = Result{Name: t.Name}
+
+			for _, name := range t.After {
+				j, ok := index[name]
+				if !ok || j == i {
+					continue
+				}
+				<-done[j]
+				if results[j].Status != ResultOK && !e.KeepGoing {
+					results[i].Status = ResultSkipped
+					results[i].Error = fmt.Sprintf("%s %s", name, results[j].Status)
+					return
+				}
+			}
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+			}
+			// A slot may free up as the run is canceled; don't start then
+			if err := ctx.Err(); err != nil {
+				results[i].Status = ResultSkipped
+				results[i].Error = err.Error()
+				return
+			}
+
+			out := ui.NewPrefixWriter(&mu, os.Stderr, fmt.Sprintf("%-*s | ", width, t.Name))
+			start := time.Now()
+			err := t.Run(compose.WithOutput(ctx, out), out)
+			out.Flush()
+			results[i].Duration = time.Since(start)
+			if err != nil {
+				results[i].Status = ResultFailed
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Status = ResultOK
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Failed returns the names of tasks that failed or were skipped.
+func Failed(results []Result) []string {
+	var names []string
+	for _, r := range results {
+		if r.Status != ResultOK {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// PrintSummary prints a table of task results.
+func PrintSummary(results []Result) {
+	rows := make([][]string, len(results))
+	for i, r := range results {
+		duration := "-"
+		if r.Status != ResultSkipped {
+			duration = r.Duration.Round(100 * time.Millisecond).String()
+		}
+		rows[i] = []string{r.Name, r.Status, duration, r.Error}
+	}
+	fmt.Println()
+	ui.PrintTable([]string{"PROJECT", "RESULT", "TIME", "ERROR"}, rows)
+	fmt.Println()
+}
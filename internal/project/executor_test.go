@@ -0,0 +1,163 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder checks that tasks start only after the tasks in the run that
+// they come after have finished.
+type recorder struct {
+	mu       sync.Mutex
+	names    map[string]bool
+	finished map[string]bool
+	errs     []string
+}
+
+func (r *recorder) task(name string, after []string, err error) Task {
+	r.names[name] = true
+	return Task{Name: name, After: after, Run: func(ctx context.Context, out io.Writer) error {
+		r.mu.Lock()
+		for _, dep := range after {
+			if r.names[dep] && dep != name && !r.finished[dep] {
+				r.errs = append(r.errs, fmt.Sprintf("%s started before %s finished", name, dep))
+			}
+		}
+		r.mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		r.mu.Lock()
+		r.finished[name] = true
+		r.mu.Unlock()
+		return err
+	}}
+}
+
+func statuses(results []Result) []string {
+	s := make([]string, len(results))
+	for i, r := range results {
+		s[i] = r.Name + "=" + r.Status
+	}
+	return s
+}
+
+func TestExecutorRun(t *testing.T) {
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		executor Executor
+		tasks    func(r *recorder) []Task
+		want     []string
+	}{
+		{
+			name:     "order, one at a time",
+			executor: Executor{Parallel: 1},
+			tasks: func(r *recorder) []Task {
+				return []Task{
+					r.task("app", []string{"api", "auth"}, nil),
+					r.task("api", []string{"db"}, nil),
+					r.task("auth", []string{"db"}, nil),
+					r.task("db", nil, nil),
+				}
+			},
+			want: []string{"app=ok", "api=ok", "auth=ok", "db=ok"},
+		},
+		{
+			name:     "order, in parallel",
+			executor: Executor{Parallel: 4},
+			tasks: func(r *recorder) []Task {
+				return []Task{
+					r.task("app", []string{"api", "auth"}, nil),
+					r.task("api", []string{"db"}, nil),
+					r.task("auth", []string{"db"}, nil),
+					r.task("db", nil, nil),
+					r.task("docs", nil, nil),
+				}
+			},
+			want: []string{"app=ok", "api=ok", "auth=ok", "db=ok", "docs=ok"},
+		},
+		{
+			name:     "dependent of a failure is skipped",
+			executor: Executor{Parallel: 2},
+			tasks: func(r *recorder) []Task {
+				return []Task{
+					r.task("db", nil, boom),
+					r.task("api", []string{"db"}, nil),
+					r.task("app", []string{"api"}, nil),
+					r.task("docs", nil, nil),
+				}
+			},
+			want: []string{"db=failed", "api=skipped", "app=skipped", "docs=ok"},
+		},
+		{
+			name:     "keep going runs the dependent",
+			executor: Executor{Parallel: 2, KeepGoing: true},
+			tasks: func(r *recorder) []Task {
+				return []Task{
+					r.task("api", []string{"db"}, nil),
+					r.task("db", nil, boom),
+				}
+			},
+			want: []string{"api=ok", "db=failed"},
+		},
+		{
+			name:     "tasks outside the run are ignored",
+			executor: Executor{Parallel: 2},
+			tasks: func(r *recorder) []Task {
+				return []Task{
+					r.task("api", []string{"ghost", "api"}, nil),
+				}
+			},
+			want: []string{"api=ok"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{names: make(map[string]bool), finished: make(map[string]bool)}
+			results := tt.executor.Run(context.Background(), tt.tasks(r))
+			if got := statuses(results); !slices.Equal(got, tt.want) {
+				t.Errorf("Run = %v, want %v", got, tt.want)
+			}
+			for _, err := range r.errs {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestExecutorRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ran []string
+	task := func(name string, after ...string) Task {
+		return Task{Name: name, After: after, Run: func(ctx context.Context, out io.Writer) error {
+			ran = append(ran, name)
+			if name == "db" {
+				cancel()
+			}
+			return nil
+		}}
+	}
+
+	results := Executor{Parallel: 1}.Run(ctx, []Task{task("db"), task("api", "db"), task("app", "api")})
+	want := []string{"db=ok", "api=skipped", "app=skipped"}
+	if got := statuses(results); !slices.Equal(got, want) {
+		t.Errorf("Run = %v, want %v", got, want)
+	}
+	if results[1].Error != context.Canceled.Error() {
+		t.Errorf("api error = %q, want %q", results[1].Error, context.Canceled.Error())
+	}
+	if !slices.Equal(ran, []string{"db"}) {
+		t.Errorf("ran %v, want only db", ran)
+	}
+}
@@ -3,12 +3,36 @@ package project
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/heysarver/devinfra/internal/config"
 	"github.com/heysarver/devinfra/internal/ui"
 )
 
-func AddFlavor(name, flavor string) error {
+// flavorTCPService is a flavor service clients reach over a TCP protocol,
+// routed through the Traefik entrypoint for that protocol.
+type flavorTCPService struct {
+	Service    string
+	Entrypoint string // see compose.TCPEntrypoints
+	Port       int    // in the container
+}
+
+// flavorTCPServices lists the TCP services of each flavor.
+var flavorTCPServices = map[string][]flavorTCPService{
+	"postgres": {{Service: "postgres", Entrypoint: "postgres", Port: 5432}},
+	"rabbitmq": {{Service: "rabbitmq", Entrypoint: "amqp", Port: 5672}},
+	"redis":    {{Service: "valkey", Entrypoint: "redis", Port: 6379}},
+}
+
+func AddFlavor(s *config.Settings, name, flavor string) error {
+	// Hold the lock across render and save so a concurrent change to the
+	// registry isn't lost when this one is written back.
+	lock, err := config.AcquireLock()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
+
 	reg, err := config.LoadRegistry()
 	if err != nil {
 		return err
@@ -52,9 +76,39 @@ func AddFlavor(name, flavor string) error {
 	if err := config.SaveRegistry(reg); err != nil {
 		return fmt.Errorf("saving registry: %w", err)
 	}
+	if err := RefreshOverlay(s, *p); err != nil {
+		return err
+	}
 
 	ui.Ok("Flavor '%s' added to '%s'.", flavor, name)
 	fmt.Fprintf(os.Stderr, "  File: %s/docker-compose.%s.yaml\n", p.Dir, flavor)
 	fmt.Fprintf(os.Stderr, "  Run 'di up %s' to apply.\n", name)
 	return nil
 }
+
+// renderMissingFlavors renders the overlay for each flavor whose
+// docker-compose.<flavor>.yaml is not already present in dir, leaving
+// committed overlays untouched. Returns the paths of the files it wrote.
+func renderMissingFlavors(s *config.Settings, name, dir string, flavors []string) ([]string, error) {
+	var written []string
+	for _, flavor := range flavors {
+		path := filepath.Join(dir, fmt.Sprintf("docker-compose.%s.yaml", flavor))
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		data := templateData{
+			ProjectName:      name,
+			TLD:              s.TLD,
+			PostgresPassword: randomPassword(24),
+			RabbitmqPassword: randomPassword(24),
+			MinioPassword:    randomPassword(24),
+			MysqlPassword:    randomPassword(24),
+		}
+		ui.Info("  Adding flavor: %s", flavor)
+		if err := renderFlavor(dir, flavor, data); err != nil {
+			return written, fmt.Errorf("rendering flavor %s: %w", flavor, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
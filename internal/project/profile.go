@@ -0,0 +1,103 @@
+package project
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/ui"
+)
+
+// AddProfiles adds compose profiles to the ones a project starts with by
+// default. Profiles no service declares are accepted with a warning, since
+// the compose files may not have caught up yet.
+func AddProfiles(s *config.Settings, name string, profiles []string) error {
+	for _, pr := range profiles {
+		if err := config.ValidateProfile(pr); err != nil {
+			return err
+		}
+	}
+
+	var added []string
+	var updated config.Project
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		p := reg.Get(name)
+		if p == nil {
+			return fmt.Errorf("project %q not found in registry", name)
+		}
+		for _, pr := range profiles {
+			if !slices.Contains(p.Profiles, pr) && !slices.Contains(added, pr) {
+				p.Profiles = append(p.Profiles, pr)
+				added = append(added, pr)
+			}
+		}
+		slices.Sort(p.Profiles)
+		updated = *p
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if len(added) == 0 {
+		ui.Info("Project '%s' already enables those profiles.", name)
+		return nil
+	}
+	WarnUnknownProfiles(updated, added)
+	ui.Ok("Default profiles for '%s': %v", name, updated.Profiles)
+	return RefreshOverlay(s, updated)
+}
+
+// RemoveProfiles removes compose profiles from a project's defaults.
+func RemoveProfiles(s *config.Settings, name string, profiles []string) error {
+	var updated config.Project
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		p := reg.Get(name)
+		if p == nil {
+			return fmt.Errorf("project %q not found in registry", name)
+		}
+		for _, pr := range profiles {
+			if !slices.Contains(p.Profiles, pr) {
+				return fmt.Errorf("profile %q is not enabled for project %q", pr, name)
+			}
+		}
+		p.Profiles = slices.DeleteFunc(p.Profiles, func(pr string) bool {
+			return slices.Contains(profiles, pr)
+		})
+		updated = *p
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	ui.Ok("Removed profiles from '%s': %v", name, profiles)
+	return RefreshOverlay(s, updated)
+}
+
+// RefreshOverlay rewrites a project's devinfra overlay so that only services
+// in p.Profiles (and services without profiles) get Traefik labels. Callers
+// starting a project with other profiles pass a copy with those set.
+func RefreshOverlay(s *config.Settings, p config.Project) error {
+	if !needsOverlay(s, p) {
+		return nil
+	}
+	if err := generateOverlay(s, p); err != nil {
+		return fmt.Errorf("generating overlay: %w", err)
+	}
+	return nil
+}
+
+// WarnUnknownProfiles warns about profiles that no service in the project's
+// compose files declares; compose silently starts nothing for them.
+func WarnUnknownProfiles(p config.Project, profiles []string) {
+	files := append([]string{baseComposeFile(p)}, p.ComposeOverrides...)
+	declared, err := compose.Profiles(p.Dir, files)
+	if err != nil {
+		return
+	}
+	for _, pr := range profiles {
+		if !slices.Contains(declared, pr) {
+			ui.Warn("No service in %s uses profile %q.", p.Name, pr)
+		}
+	}
+}
@@ -3,6 +3,10 @@ package project
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/heysarver/devinfra/internal/compose"
@@ -17,11 +21,22 @@ import (
 // Partial failures do not abort the run — failed projects are collected and
 // reported at the end. The function returns a non-nil error if any project
 // failed.
-func RegenerateAll(ctx context.Context) error {
+func RegenerateAll(ctx context.Context, s *config.Settings) error {
+	return Regenerate(ctx, s, nil)
+}
+
+// Regenerate is RegenerateAll restricted to the named projects. Core
+// infrastructure is only restarted when regenerating everything (names == nil).
+func Regenerate(ctx context.Context, s *config.Settings, names []string) error {
 	reg, err := config.LoadRegistry()
 	if err != nil {
 		return fmt.Errorf("loading registry: %w", err)
 	}
+	if names != nil {
+		reg.Projects = slices.DeleteFunc(reg.Projects, func(p config.Project) bool {
+			return !slices.Contains(names, p.Name)
+		})
+	}
 
 	// Snapshot running state before stopping anything
 	running, err := compose.RunningContainers(ctx)
@@ -29,7 +44,7 @@ func RegenerateAll(ctx context.Context) error {
 		ui.Warn("Could not determine running containers: %v", err)
 	}
 
-	infraWasRunning := compose.IsInfraRunning(ctx)
+	infraWasRunning := names == nil && compose.IsInfraRunning(ctx)
 
 	var failures []string
 
@@ -39,42 +54,67 @@ func RegenerateAll(ctx context.Context) error {
 
 		// Stop if running
 		if wasRunning {
-			ui.Info("Stopping %s...", p.Name)
+			// Restart (and label) it with the profiles it is running with,
+			// which may differ from its defaults
 			files := p.ComposeFiles()
+			if active, err := compose.ActiveProfiles(ctx, p.Name, p.Dir, files); err == nil && active != nil {
+				p.Profiles = active
+			}
+
+			ui.Info("Stopping %s...", p.Name)
 			if err := compose.ProjectDown(ctx, p.Name, p.Dir, files); err != nil {
 				ui.Warn("Failed to stop %s: %v", p.Name, err)
 				// Continue — project may already be stopped
 			}
 		}
 
-		// Rewrite overlay (non-host-mode projects with services only)
-		if !p.HostMode && len(p.Services) > 0 {
+		// Update domain in registry to match current TLD before rendering
+		// routes from it
+		p.Domain = fmt.Sprintf("*.%s.%s", p.Name, s.TLD)
+
+		// Rewrite overlay for docker services and flavor TCP routes
+		if needsOverlay(s, *p) {
 			ui.Info("Regenerating overlay for %s...", p.Name)
-			if err := generateOverlay(p.Name, p.Dir, p.Services, config.Remote()); err != nil {
+			if err := generateOverlay(s, *p); err != nil {
 				ui.Warn("Failed to regenerate overlay for %s: %v", p.Name, err)
 				failures = append(failures, p.Name)
 				continue
 			}
+		} else {
+			// Nothing left to route, e.g. after turning off TCP routing
+			_ = os.Remove(filepath.Join(p.Dir, "docker-compose.devinfra.yaml"))
 		}
 
 		// Remove old certs (handles TLD change — cleans up old-TLD filenames)
-		_ = compose.RemoveCerts(p.Name)
+		_ = compose.RemoveCerts(s, p.Name, p.ExtraDomains)
 
 		// Generate new certs
 		ui.Info("Regenerating certs for %s...", p.Name)
-		if err := compose.GenerateCerts(ctx, p.Name); err != nil {
+		if err := compose.GenerateCerts(ctx, s, p.Name, p.ExtraDomains); err != nil {
 			ui.Warn("Failed to regenerate certs for %s: %v", p.Name, err)
 			failures = append(failures, p.Name)
 			continue
 		}
 
-		// Update domain in registry to match current TLD
-		p.Domain = fmt.Sprintf("*.%s.%s", p.Name, config.TLD())
+		// Rewrite host config for host services
+		if err := generateHostConfig(*p); err != nil {
+			ui.Warn("Failed to regenerate host config for %s: %v", p.Name, err)
+			failures = append(failures, p.Name)
+			continue
+		}
 	}
 
-	// Save updated registry (domain fields reflect new TLD)
-	if err := config.SaveRegistry(reg); err != nil {
-		return fmt.Errorf("saving registry: %w", err)
+	// Save updated domain fields (reflecting the new TLD) against a freshly
+	// locked registry so projects added or changed meanwhile are preserved.
+	if err := config.UpdateRegistry(func(latest *config.Registry) error {
+		for _, p := range reg.Projects {
+			if entry := latest.Get(p.Name); entry != nil {
+				entry.Domain = p.Domain
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	// Restart infra if it was running (picks up re-extracted dnsmasq.conf)
@@ -94,16 +134,34 @@ func RegenerateAll(ctx context.Context) error {
 		failedSet[name] = true
 	}
 
+	// Dependencies restart first, unless a hand-edited registry has a cycle
+	ordered := reg.CheckCycles() == nil
+	var tasks []Task
 	for _, p := range reg.Projects {
-		if !failedSet[p.Name] && len(running[p.Name]) > 0 {
-			ui.Info("Restarting %s...", p.Name)
-			files := p.ComposeFiles()
-			if err := compose.ProjectUp(ctx, p.Name, p.Dir, files); err != nil {
-				ui.Warn("Failed to restart %s: %v", p.Name, err)
-				failures = append(failures, p.Name)
-			} else {
-				ui.Ok("Restarted %s", p.Name)
-			}
+		if failedSet[p.Name] || len(running[p.Name]) == 0 {
+			continue
+		}
+		var after []string
+		if ordered {
+			after = p.DependsOn
+		}
+		tasks = append(tasks, Task{
+			Name:  p.Name,
+			After: after,
+			Run: func(ctx context.Context, out io.Writer) error {
+				ui.InfoTo(out, "Restarting %s...", p.Name)
+				if err := compose.ProjectUp(ctx, p.Name, p.Dir, p.ComposeFiles(), p.Profiles); err != nil {
+					return err
+				}
+				ui.OkTo(out, "Restarted %s", p.Name)
+				return nil
+			},
+		})
+	}
+	for _, r := range (Executor{Parallel: DefaultParallel}).Run(ctx, tasks) {
+		if r.Status != ResultOK {
+			ui.Warn("Failed to restart %s: %s", r.Name, r.Error)
+			failures = append(failures, r.Name)
 		}
 	}
 
@@ -4,13 +4,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/heysarver/devinfra/internal/compose"
 	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/supervisor"
 	"github.com/heysarver/devinfra/internal/ui"
 )
 
-func Remove(ctx context.Context, name string, removeDir bool) error {
+func Remove(ctx context.Context, s *config.Settings, name string, removeDir bool) error {
 	reg, err := config.LoadRegistry()
 	if err != nil {
 		return err
@@ -23,25 +25,34 @@ func Remove(ctx context.Context, name string, removeDir bool) error {
 
 	projectDir := p.Dir
 
+	if dependents := reg.Dependents(name); len(dependents) > 0 {
+		ui.Warn("These projects depend on '%s' and won't start until it is registered again: %s", name, strings.Join(dependents, ", "))
+	}
+
 	// Stop project containers (include all compose files: base, devinfra overlay, flavors)
 	files := p.ComposeFiles()
 	if len(files) > 0 {
 		ui.Info("Stopping project containers...")
 		_ = compose.ProjectDown(ctx, p.Name, projectDir, files)
 	}
+	if _, ok := supervisor.Running(name); ok {
+		ui.Info("Stopping host processes...")
+		_ = supervisor.Stop(name)
+	}
+	_ = os.RemoveAll(supervisor.LogDir(name))
 
 	// Remove certs
 	ui.Info("Removing certs...")
-	_ = compose.RemoveCerts(name)
+	_ = compose.RemoveCerts(s, name, p.ExtraDomains)
+	_ = os.Remove(hostConfigPath(name))
 
 	// Remove from registry
 	ui.Info("Removing from registry...")
-	if err := reg.Remove(name); err != nil {
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		return reg.Remove(name)
+	}); err != nil {
 		return err
 	}
-	if err := config.SaveRegistry(reg); err != nil {
-		return fmt.Errorf("saving registry: %w", err)
-	}
 
 	// Remove project directory if requested
 	if removeDir {
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/heysarver/devinfra/internal/compose"
 	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/supervisor"
 	"github.com/heysarver/devinfra/internal/ui"
 )
 
@@ -14,6 +18,8 @@ type RenameOpts struct {
 	OldName string
 	NewName string // if same as OldName, only dir changes
 	NewDir  string // if empty, keep existing dir
+
+	Settings *config.Settings // configuration resolved for this invocation
 }
 
 func Rename(ctx context.Context, opts RenameOpts) error {
@@ -58,44 +64,77 @@ func Rename(ctx context.Context, opts RenameOpts) error {
 			ui.Info("Stopping project containers...")
 			_ = compose.ProjectDown(ctx, p.Name, p.Dir, files)
 		}
+		if _, ok := supervisor.Running(opts.OldName); ok {
+			ui.Info("Stopping host processes...")
+			_ = supervisor.Stop(opts.OldName)
+		}
+		_ = os.RemoveAll(supervisor.LogDir(opts.OldName))
 
 		// Remove old certs, TLS config, and host config (if any)
-		ui.Info("Removing old certs for %s.%s...", opts.OldName, config.TLD())
-		_ = compose.RemoveCerts(opts.OldName)
+		ui.Info("Removing old certs for %s.%s...", opts.OldName, opts.Settings.TLD)
+		_ = compose.RemoveCerts(opts.Settings, opts.OldName, p.ExtraDomains)
+		_ = os.Remove(hostConfigPath(opts.OldName))
 
 		// Generate certs for new domain
-		if err := compose.GenerateCerts(ctx, opts.NewName); err != nil {
+		if err := compose.GenerateCerts(ctx, opts.Settings, opts.NewName, p.ExtraDomains); err != nil {
 			return fmt.Errorf("generating certs: %w", err)
 		}
 
-		// For host-mode: regenerate the Traefik file-provider config with new name
-		if p.HostMode {
-			dir := p.Dir
+		// For host services: regenerate the Traefik file-provider config with new name
+		if len(p.HostServices()) > 0 {
+			renamed := *p
+			renamed.Name = opts.NewName
+			renamed.Domain = fmt.Sprintf("*.%s.%s", opts.NewName, opts.Settings.TLD)
 			if dirChanged {
-				dir = opts.NewDir
+				renamed.Dir = opts.NewDir
 			}
-			if err := generateHostConfig(opts.NewName, dir, p.Services); err != nil {
+			if err := generateHostConfig(renamed); err != nil {
 				return fmt.Errorf("regenerating host config: %w", err)
 			}
 		}
 	}
 
-	// Update registry entry in-place
-	for i := range reg.Projects {
-		if reg.Projects[i].Name == opts.OldName {
-			if nameChanged {
-				reg.Projects[i].Name = opts.NewName
-				reg.Projects[i].Domain = fmt.Sprintf("*.%s.%s", opts.NewName, config.TLD())
-			}
-			if dirChanged {
-				reg.Projects[i].Dir = opts.NewDir
+	// Update registry entry against a freshly locked copy so changes made by
+	// another di while containers were stopping aren't overwritten.
+	var dependents []config.Project
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		entry := reg.Get(opts.OldName)
+		if entry == nil {
+			return fmt.Errorf("project %q not found in registry", opts.OldName)
+		}
+		if nameChanged {
+			if reg.Get(opts.NewName) != nil {
+				return fmt.Errorf("project %q already exists", opts.NewName)
 			}
-			break
+			entry.Name = opts.NewName
+			entry.Domain = fmt.Sprintf("*.%s.%s", opts.NewName, opts.Settings.TLD)
+			dependents = reg.RenameDependency(opts.OldName, opts.NewName)
+		}
+		if dirChanged {
+			entry.Dir = opts.NewDir
 		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	if err := config.SaveRegistry(reg); err != nil {
-		return fmt.Errorf("saving registry: %w", err)
+	for _, d := range dependents {
+		ui.Info("Project '%s' now depends on '%s'.", d.Name, opts.NewName)
+		m, err := config.LoadManifest(d.Dir)
+		if err == nil && m != nil && slices.Contains(m.DependsOn, opts.OldName) {
+			ui.Warn("%s in %s still depends on '%s'; update it to '%s' before the next 'di sync %s'.",
+				config.ManifestFileName, d.Dir, opts.OldName, opts.NewName, d.Name)
+		}
+	}
+
+	if nameChanged {
+		dir := p.Dir
+		if dirChanged {
+			dir = opts.NewDir
+		}
+		if err := renameInMakefile(dir, opts.OldName, opts.NewName); err != nil {
+			ui.Warn("Could not update PROJECT_NAME in the Makefile: %v", err)
+		}
 	}
 
 	displayName := opts.NewName
@@ -114,7 +153,7 @@ func Rename(ctx context.Context, opts RenameOpts) error {
 	if nameChanged && !p.HostMode {
 		fmt.Fprintln(os.Stderr)
 		ui.Warn("docker-compose files may still reference '%s' in Traefik labels and network names.", opts.OldName)
-		fmt.Fprintf(os.Stderr, "  Update Host rules and router names to use '%s.%s', then run:\n", opts.NewName, config.TLD())
+		fmt.Fprintf(os.Stderr, "  Update Host rules and router names to use '%s.%s', then run:\n", opts.NewName, opts.Settings.TLD)
 		fmt.Fprintf(os.Stderr, "  di up %s\n", displayName)
 	} else if nameChanged {
 		fmt.Fprintln(os.Stderr)
@@ -123,3 +162,30 @@ func Rename(ctx context.Context, opts RenameOpts) error {
 
 	return nil
 }
+
+// renameInMakefile points the PROJECT_NAME line of a Makefile generated by
+// 'di new' at the project's new name, so its targets keep finding it. A
+// missing Makefile, or one that doesn't name the old project, is left alone.
+func renameInMakefile(dir, oldName, newName string) error {
+	path := filepath.Join(dir, "Makefile")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	changed := false
+	for i, line := range lines {
+		content := strings.TrimRight(line, "\r\n")
+		if content == "PROJECT_NAME := "+oldName {
+			lines[i] = "PROJECT_NAME := " + newName + line[len(content):]
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "")), 0644)
+}
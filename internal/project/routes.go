@@ -0,0 +1,81 @@
+package project
+
+import (
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/ui"
+)
+
+// Routes returns the URL each of p's services is served on through Traefik,
+// under the project's primary domain. Services whose existing routers are
+// left alone have no devinfra route, and docker services in compose profiles
+// that aren't enabled are not running, so both are left out.
+func Routes(p config.Project) []compose.Route {
+	var detected map[string]compose.DetectedService
+	if len(p.DockerServices()) > 0 {
+		var err error
+		if detected, err = composeServices(p); err != nil {
+			ui.Warn("Could not read compose profiles for %s: %v", p.Name, err)
+		}
+	}
+
+	bases := p.BaseDomains()[:1]
+	var routes []compose.Route
+	for _, svc := range p.Services {
+		host := p.HostMode || svc.Host
+		if !host && (svc.Routing == config.RoutingSkip || !compose.ProfileEnabled(detected[svc.Name].Profiles, p.Profiles)) {
+			continue
+		}
+		// The last host is the service's own, rather than the root domain
+		// the first service also answers on
+		hosts := p.ServiceHosts(svc, bases)
+		routes = append(routes, compose.Route{
+			Service: svc.Name,
+			URL:     "https://" + hosts[len(hosts)-1],
+			Port:    svc.Port,
+			Host:    host,
+		})
+	}
+	return routes
+}
+
+// TCPRoute is a flavor service reachable through a Traefik TCP entrypoint
+// at Host:Port, with TLS and the host name as SNI.
+type TCPRoute struct {
+	Service    string   `json:"service"`
+	Host       string   `json:"host"`
+	Port       int      `json:"port"`
+	Entrypoint string   `json:"entrypoint"`
+	Target     int      `json:"target"`            // port in the container
+	Aliases    []string `json:"aliases,omitempty"` // the host under extra domains
+}
+
+// TCPRoutes returns the TCP routes of p's flavors, or none if tcp.routing is
+// disabled.
+func TCPRoutes(s *config.Settings, p config.Project) []TCPRoute {
+	if !s.TCPRouting {
+		return nil
+	}
+	bases := p.BaseDomains()
+	var routes []TCPRoute
+	for _, flavor := range p.Flavors {
+		for _, svc := range flavorTCPServices[flavor] {
+			ep, ok := compose.LookupTCPEntrypoint(svc.Entrypoint)
+			if !ok {
+				continue
+			}
+			r := TCPRoute{Service: svc.Service, Host: svc.Service + "." + bases[0], Port: ep.Port, Entrypoint: ep.Name, Target: svc.Port}
+			for _, base := range bases[1:] {
+				r.Aliases = append(r.Aliases, svc.Service+"."+base)
+			}
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// needsOverlay reports whether p has anything for the devinfra overlay to
+// route: docker services, or flavor services with TCP routes.
+func needsOverlay(s *config.Settings, p config.Project) bool {
+	return len(p.DockerServices()) > 0 || len(TCPRoutes(s, p)) > 0
+}
@@ -0,0 +1,194 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/heysarver/devinfra/internal/compose"
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/ui"
+)
+
+// Sync reconciles a registered project with the .devinfra.yaml manifest in its
+// directory. The manifest is the source of truth: services, flavors, compose
+// files, domains, and mode are copied into the registry, missing flavor
+// overlays are rendered, and the routing config and certs are regenerated. With
+// dryRun, the changes are only reported.
+func Sync(ctx context.Context, s *config.Settings, name string, dryRun bool) error {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+	p := reg.Get(name)
+	if p == nil {
+		return fmt.Errorf("project %q not found in registry", name)
+	}
+
+	m, err := config.LoadManifest(p.Dir)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return fmt.Errorf("no %s found in %s", config.ManifestFileName, p.Dir)
+	}
+
+	if m.Name != "" && m.Name != p.Name {
+		ui.Warn("%s names the project %q but it is registered as %q; run 'di rename %s %s' to match.",
+			config.ManifestFileName, m.Name, p.Name, p.Name, m.Name)
+	}
+
+	want := *p
+	m.ApplyTo(&want)
+	keepRouting(p.Services, want.Services)
+	warnUnregisteredDependencies(reg, want)
+
+	changes := diffProjects(*p, want)
+	if len(changes) == 0 {
+		ui.Ok("Project '%s' is in sync with %s.", name, config.ManifestFileName)
+		return nil
+	}
+
+	for _, c := range changes {
+		fmt.Fprintf(os.Stderr, "  %s\n", c)
+	}
+	if dryRun {
+		ui.Info("Dry run: %d change(s) would be applied to '%s'.", len(changes), name)
+		return nil
+	}
+
+	if _, err := renderMissingFlavors(s, want.Name, want.Dir, want.Flavors); err != nil {
+		return err
+	}
+
+	if err := generateHostConfig(want); err != nil {
+		return fmt.Errorf("generating host config: %w", err)
+	}
+	overlay := filepath.Join(want.Dir, "docker-compose.devinfra.yaml")
+	if needsOverlay(s, want) {
+		if err := generateOverlay(s, want); err != nil {
+			return fmt.Errorf("generating overlay: %w", err)
+		}
+	} else {
+		_ = os.Remove(overlay)
+	}
+
+	if !slices.Equal(p.ExtraDomains, want.ExtraDomains) {
+		_ = compose.RemoveCerts(s, p.Name, p.ExtraDomains)
+		if err := compose.GenerateCerts(ctx, s, want.Name, want.ExtraDomains); err != nil {
+			return fmt.Errorf("generating certs: %w", err)
+		}
+	}
+
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		entry := reg.Get(name)
+		if entry == nil {
+			return fmt.Errorf("project %q not found in registry", name)
+		}
+		entry.HostMode = want.HostMode
+		entry.Services = want.Services
+		entry.Flavors = want.Flavors
+		entry.ComposeFile = want.ComposeFile
+		entry.ComposeOverrides = want.ComposeOverrides
+		entry.ExtraDomains = want.ExtraDomains
+		entry.DependsOn = want.DependsOn
+		entry.Profiles = want.Profiles
+		return reg.CheckCycles()
+	}); err != nil {
+		return err
+	}
+
+	ui.Ok("Project '%s' synced with %s.", name, config.ManifestFileName)
+	fmt.Fprintf(os.Stderr, "  Run 'di up %s' to apply.\n", name)
+	return nil
+}
+
+// diffProjects describes the manifest-managed fields that differ between the
+// registered project and the desired one.
+func diffProjects(have, want config.Project) []string {
+	var changes []string
+	if have.HostMode != want.HostMode {
+		changes = append(changes, fmt.Sprintf("mode: %s → %s", have.Mode(), want.Mode()))
+	}
+	if !slices.Equal(have.Services, want.Services) {
+		changes = append(changes, fmt.Sprintf("services: %s → %s", formatServices(have.Services), formatServices(want.Services)))
+	}
+	if !slices.Equal(have.Flavors, want.Flavors) {
+		changes = append(changes, fmt.Sprintf("flavors: [%s] → [%s]", strings.Join(have.Flavors, ", "), strings.Join(want.Flavors, ", ")))
+	}
+	if !slices.Equal(have.ExtraDomains, want.ExtraDomains) {
+		changes = append(changes, fmt.Sprintf("extra domains: [%s] → [%s]", strings.Join(have.ExtraDomains, ", "), strings.Join(want.ExtraDomains, ", ")))
+	}
+	if !slices.Equal(have.Profiles, want.Profiles) {
+		changes = append(changes, fmt.Sprintf("profiles: [%s] → [%s]", strings.Join(have.Profiles, ", "), strings.Join(want.Profiles, ", ")))
+	}
+	if !slices.Equal(have.DependsOn, want.DependsOn) {
+		changes = append(changes, fmt.Sprintf("depends on: [%s] → [%s]", strings.Join(have.DependsOn, ", "), strings.Join(want.DependsOn, ", ")))
+	}
+	haveFiles := append([]string{baseComposeFile(have)}, have.ComposeOverrides...)
+	wantFiles := append([]string{baseComposeFile(want)}, want.ComposeOverrides...)
+	if !slices.Equal(haveFiles, wantFiles) {
+		changes = append(changes, fmt.Sprintf("compose files: [%s] → [%s]", strings.Join(haveFiles, ", "), strings.Join(wantFiles, ", ")))
+	}
+	return changes
+}
+
+// baseComposeFile returns the project's base compose filename, applying the
+// same default as Project.ComposeFiles.
+func baseComposeFile(p config.Project) string {
+	if p.ComposeFile == "" {
+		return "docker-compose.yaml"
+	}
+	return p.ComposeFile
+}
+
+func formatServices(services []config.Service) string {
+	parts := make([]string, len(services))
+	for i, s := range services {
+		parts[i] = fmt.Sprintf("%s:%d", s.Name, s.Port)
+		if s.Subdomain != "" {
+			parts[i] += " (" + s.Subdomain + ")"
+		}
+		if s.Host {
+			parts[i] += " [host]"
+		}
+		if s.Routing != config.RoutingGenerate {
+			parts[i] += " [" + s.Routing + "]"
+		}
+		if s.Command != "" {
+			parts[i] += " `" + s.Command + "`"
+		}
+		if s.Workdir != "" {
+			parts[i] += " in " + s.Workdir
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// warnUnregisteredDependencies warns about depends_on entries that name
+// projects which aren't registered yet; 'di up' fails until they are.
+func warnUnregisteredDependencies(reg *config.Registry, p config.Project) {
+	for _, dep := range p.DependsOn {
+		if reg.Get(dep) == nil {
+			ui.Warn("Project '%s' depends on '%s', which is not registered yet; add it before running 'di up %s'.", p.Name, dep, p.Name)
+		}
+	}
+}
+
+// keepRouting carries the routing choice made at import over to manifest
+// services that don't set one, so a sync doesn't re-add duplicate routers.
+func keepRouting(have, want []config.Service) {
+	for i := range want {
+		if want[i].Routing != config.RoutingGenerate {
+			continue
+		}
+		for _, s := range have {
+			if s.Name == want[i].Name {
+				want[i].Routing = s.Routing
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package project
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/ui"
+)
+
+// AddTags attaches tags to a project. Tags it already carries are skipped.
+func AddTags(name string, tags []string) error {
+	for _, t := range tags {
+		if err := config.ValidateTag(t); err != nil {
+			return err
+		}
+	}
+
+	var added []string
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		p := reg.Get(name)
+		if p == nil {
+			return fmt.Errorf("project %q not found in registry", name)
+		}
+		for _, t := range tags {
+			if !p.HasTag(t) && !slices.Contains(added, t) {
+				p.Tags = append(p.Tags, t)
+				added = append(added, t)
+			}
+		}
+		slices.Sort(p.Tags)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if len(added) == 0 {
+		ui.Info("Project '%s' already has those tags.", name)
+		return nil
+	}
+	ui.Ok("Tagged '%s': %v", name, added)
+	return nil
+}
+
+// RemoveTags detaches tags from a project. Tags it doesn't carry are an error.
+func RemoveTags(name string, tags []string) error {
+	if err := config.UpdateRegistry(func(reg *config.Registry) error {
+		p := reg.Get(name)
+		if p == nil {
+			return fmt.Errorf("project %q not found in registry", name)
+		}
+		for _, t := range tags {
+			if !p.HasTag(t) {
+				return fmt.Errorf("project %q is not tagged %q", name, t)
+			}
+		}
+		p.Tags = slices.DeleteFunc(p.Tags, func(t string) bool {
+			return slices.Contains(tags, t)
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	ui.Ok("Removed tags from '%s': %v", name, tags)
+	return nil
+}
@@ -0,0 +1,209 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/heysarver/devinfra/internal/config"
+)
+
+// LogDir returns the directory holding a project's process logs.
+func LogDir(project string) string { return filepath.Join(config.LogsDir(), project) }
+
+// LogPath returns the log file of one of a project's processes.
+func LogPath(project, proc string) string { return filepath.Join(LogDir(project), proc+".log") }
+
+func pidPath(project string) string { return filepath.Join(config.RunDir(), project+".pid") }
+
+// Start launches a detached 'di supervise' for project that outlives the
+// calling command, and waits for it to claim the project's pid file. The
+// supervisor's own errors go to run/<project>.log.
+func Start(project string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.RunDir(), 0700); err != nil {
+		return err
+	}
+	logPath := filepath.Join(config.RunDir(), project+".log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "supervise", project)
+	cmd.Dir = "/"
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	deadline := time.After(startTimeout)
+	for {
+		if pid, ok := Running(project); ok && pid == cmd.Process.Pid {
+			return nil
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("supervisor exited at startup; see %s", logPath)
+		case <-deadline:
+			return fmt.Errorf("supervisor did not start within %s; see %s", startTimeout, logPath)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// PidFile is a project's pid file, locked by its supervisor for as long as
+// it runs. The lock, not the pid, says whether a supervisor is live: the
+// kernel drops it when the supervisor dies however it dies, whereas a pid
+// left behind by a crash or reboot may since belong to another process.
+type PidFile struct {
+	f *os.File
+}
+
+// Claim locks project's pid file and records the calling process in it. It
+// fails if another supervisor of the project holds it.
+func Claim(project string) (*PidFile, error) {
+	if err := os.MkdirAll(config.RunDir(), 0700); err != nil {
+		return nil, err
+	}
+	path := pidPath(project)
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			f.Close()
+			if !errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, fmt.Errorf("locking %s: %w", path, err)
+			}
+			if pid, ok := Running(project); ok {
+				return nil, fmt.Errorf("a supervisor of %s is already running (pid %d)", project, pid)
+			}
+			// Only briefly held, by Running or Stop checking on it
+			if attempt >= 20 {
+				return nil, fmt.Errorf("%s is locked", path)
+			}
+			time.Sleep(50 * time.Millisecond)
+			continue
+		}
+		// Stop removes the file once its supervisor is gone; if that
+		// happened between opening and locking, lock the new one instead
+		if !samePath(f, path) {
+			f.Close()
+			continue
+		}
+		if err := f.Truncate(0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &PidFile{f: f}, nil
+	}
+}
+
+// Release clears the recorded pid and drops the lock.
+func (p *PidFile) Release() {
+	if p == nil || p.f == nil {
+		return
+	}
+	_ = p.f.Truncate(0)
+	_ = p.f.Close() // closing drops the flock
+	p.f = nil
+}
+
+// Running returns the pid of project's supervisor if one holds its pid file.
+func Running(project string) (int, bool) {
+	f, err := os.Open(pidPath(project))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err == nil {
+		// Nobody holds it, so whatever pid it records is stale
+		return 0, false
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		// Locked, but the pid isn't written yet
+		return 0, false
+	}
+	return pid, true
+}
+
+// Stop stops project's supervisor, which stops its processes first, and
+// waits for it to exit. It is a no-op if no supervisor is running, and never
+// signals a process that doesn't hold the project's pid file.
+func Stop(project string) error {
+	pid, ok := Running(project)
+	if !ok {
+		removePidFile(project)
+		return nil
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	// Give the supervisor time to stop processes that ignore SIGTERM
+	deadline := time.Now().Add(stopTimeout + 5*time.Second)
+	for {
+		holder, ok := Running(project)
+		if !ok || holder != pid {
+			break
+		}
+		if time.Now().After(deadline) {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+			return fmt.Errorf("supervisor of %s (pid %d) did not stop in time and was killed", project, pid)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	removePidFile(project)
+	return nil
+}
+
+// removePidFile removes project's pid file unless a supervisor holds it.
+func removePidFile(project string) {
+	path := pidPath(project)
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB) == nil && samePath(f, path) {
+		_ = os.Remove(path)
+	}
+}
+
+// samePath reports whether f is still the file at path.
+func samePath(f *os.File, path string) bool {
+	a, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	b, err := os.Stat(path)
+	return err == nil && os.SameFile(a, b)
+}
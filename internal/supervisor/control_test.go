@@ -0,0 +1,69 @@
+package supervisor
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/heysarver/devinfra/internal/config"
+)
+
+func TestStalePidFile(t *testing.T) {
+	t.Setenv("DEVINFRA_HOME", t.TempDir())
+
+	// A live process that isn't a supervisor, as when a crashed
+	// supervisor's pid has been reused
+	other := exec.Command("sleep", "30")
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = other.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		_ = other.Process.Kill()
+		<-exited
+	})
+
+	if err := os.MkdirAll(config.RunDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pidPath("myapp"), []byte(strconv.Itoa(other.Process.Pid)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if pid, ok := Running("myapp"); ok {
+		t.Errorf("Running = %d, true for a pid file no supervisor holds", pid)
+	}
+	if err := Stop("myapp"); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	select {
+	case <-exited:
+		t.Fatal("Stop signaled a process that isn't the supervisor")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if _, err := os.Stat(pidPath("myapp")); !os.IsNotExist(err) {
+		t.Errorf("Stop left the stale pid file behind")
+	}
+
+	// Claiming it makes this process the running supervisor
+	pidFile, err := Claim("myapp")
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if pid, ok := Running("myapp"); !ok || pid != os.Getpid() {
+		t.Errorf("Running = %d, %v, want %d, true", pid, ok, os.Getpid())
+	}
+	if _, err := Claim("myapp"); err == nil {
+		t.Errorf("second Claim succeeded while the pid file is held")
+	}
+	pidFile.Release()
+	if _, ok := Running("myapp"); ok {
+		t.Errorf("Running after Release")
+	}
+}
@@ -0,0 +1,142 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heysarver/devinfra/internal/compose"
+)
+
+// pollInterval is how often a followed log is checked for new output.
+const pollInterval = 250 * time.Millisecond
+
+// FollowLog writes a process log to w, honoring opts' Since and Tail, and
+// then follows it unless opts.NoFollow is set. Lines keep the timestamps the
+// supervisor gave them. A log that doesn't exist yet is waited for, and a log
+// restarted by a new supervisor is read again from the start.
+func FollowLog(ctx context.Context, path string, opts compose.LogOptions, w io.Writer) error {
+	since, err := parseSince(opts.Since)
+	if err != nil {
+		return err
+	}
+	tail := -1
+	if opts.Tail != "" && opts.Tail != "all" {
+		if tail, err = strconv.Atoi(opts.Tail); err != nil || tail < 0 {
+			return fmt.Errorf("invalid tail %q: must be a number of lines or \"all\"", opts.Tail)
+		}
+	}
+
+	f, err := openLog(ctx, path, !opts.NoFollow)
+	if f == nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	partial := lines[len(lines)-1] // "" when the log ends in a newline
+	lines = lines[:len(lines)-1]
+	if !since.IsZero() {
+		kept := lines[:0]
+		for _, line := range lines {
+			if ts, _, ok := strings.Cut(line, " "); ok {
+				if t, err := time.Parse(time.RFC3339Nano, ts); err == nil && t.Before(since) {
+					continue
+				}
+			}
+			kept = append(kept, line)
+		}
+		lines = kept
+	}
+	if tail >= 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	if _, err := io.WriteString(w, strings.Join(lines, "")+partial); err != nil {
+		return err
+	}
+	if opts.NoFollow {
+		return nil
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if replaced(f, path) {
+			next, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			f.Close()
+			f = next
+		}
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+	}
+}
+
+// openLog opens a log file, waiting for it to appear if wait is set. It
+// returns a nil file if the log doesn't exist and wait is unset, or if ctx
+// is done first.
+func openLog(ctx context.Context, path string, wait bool) (*os.File, error) {
+	for {
+		f, err := os.Open(path)
+		if err == nil {
+			return f, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if !wait {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// replaced reports whether the log at path is no longer what f reads, because
+// it was recreated or truncated.
+func replaced(f *os.File, path string) bool {
+	cur, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	now, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	pos, err := f.Seek(0, io.SeekCurrent)
+	return !os.SameFile(cur, now) || (err == nil && now.Size() < pos)
+}
+
+// parseSince parses a --since value, a duration ago or an RFC 3339 timestamp.
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q: must be a duration like 10m or an RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,50 @@
+package supervisor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ProcfileName is the Procfile read from a project directory.
+const ProcfileName = "Procfile"
+
+// procfileLine matches a "name: command" Procfile entry.
+var procfileLine = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.+)$`)
+
+// ReadProcfile parses a Procfile into processes without a directory or port.
+// A missing file yields no processes.
+func ReadProcfile(path string) ([]Process, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return parseProcfile(f.Name(), bufio.NewScanner(f))
+}
+
+func parseProcfile(name string, sc *bufio.Scanner) ([]Process, error) {
+	var procs []Process
+	seen := make(map[string]bool)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := procfileLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("%s:%d: expected \"name: command\"", name, n)
+		}
+		if seen[m[1]] {
+			return nil, fmt.Errorf("%s:%d: duplicate process %q", name, n, m[1])
+		}
+		seen[m[1]] = true
+		procs = append(procs, Process{Name: m[1], Command: strings.TrimSpace(m[2])})
+	}
+	return procs, sc.Err()
+}
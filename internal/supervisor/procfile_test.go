@@ -0,0 +1,97 @@
+package supervisor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/heysarver/devinfra/internal/config"
+)
+
+func TestParseProcfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Process
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"entries", "web: npm run dev -- --port $PORT\n\n# background jobs\nworker:bin/worker\n", []Process{
+			{Name: "web", Command: "npm run dev -- --port $PORT"},
+			{Name: "worker", Command: "bin/worker"},
+		}, false},
+		{"missing command", "web:\n", nil, true},
+		{"invalid name", "web app: npm start\n", nil, true},
+		{"duplicate", "web: a\nweb: b\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProcfile("Procfile", bufio.NewScanner(strings.NewReader(tt.content)))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseProcfile: expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseProcfile: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseProcfile = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcesses(t *testing.T) {
+	dir := t.TempDir()
+	procfile := "web: npm run dev\napi: go run ./cmd/api\nworker: bin/worker\n"
+	if err := os.WriteFile(filepath.Join(dir, ProcfileName), []byte(procfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	services := []config.Service{
+		{Name: "web", Port: 3000, Command: "pnpm dev", Workdir: "frontend"},
+		{Name: "api", Port: 4000},
+		{Name: "docs", Port: 5000},
+	}
+
+	tests := []struct {
+		name    string
+		project config.Project
+		want    []Process
+	}{
+		{
+			name:    "host mode",
+			project: config.Project{Dir: dir, HostMode: true, Services: services},
+			want: []Process{
+				{Name: "web", Command: "pnpm dev", Dir: filepath.Join(dir, "frontend"), Port: 3000},
+				{Name: "api", Command: "go run ./cmd/api", Dir: dir, Port: 4000},
+				{Name: "worker", Command: "bin/worker", Dir: dir},
+			},
+		},
+		{
+			name: "mixed runs only host services",
+			project: config.Project{Dir: dir, Services: []config.Service{
+				{Name: "web", Port: 3000, Host: true},
+				{Name: "api", Port: 4000},
+			}},
+			want: []Process{{Name: "web", Command: "npm run dev", Dir: dir, Port: 3000}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Processes(tt.project)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Processes = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
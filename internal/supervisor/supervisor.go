@@ -0,0 +1,187 @@
+// Package supervisor runs the commands of host services for host-mode and
+// mixed projects: it restarts them when they crash and stops them with the
+// project, writing their output to log files that 'di logs' follows.
+package supervisor
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/heysarver/devinfra/internal/config"
+	"github.com/heysarver/devinfra/internal/ui"
+)
+
+const (
+	minBackoff   = time.Second
+	maxBackoff   = 30 * time.Second
+	stableAfter  = 10 * time.Second // a process up this long restarts without delay again
+	stopTimeout  = 10 * time.Second // between SIGTERM and SIGKILL
+	startTimeout = 5 * time.Second  // for a new supervisor to claim its pid file
+)
+
+// Process is a command run on the host for a project.
+type Process struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	Dir     string `json:"dir"`
+	Port    int    `json:"port,omitempty"` // exported as PORT; 0 for processes without a service
+}
+
+// Processes returns the processes to supervise for p. A host service runs its
+// registry command, or else the Procfile entry of the same name. Host-mode
+// projects also run the Procfile's remaining entries, such as workers.
+func Processes(p config.Project) ([]Process, error) {
+	entries, err := ReadProcfile(filepath.Join(p.Dir, ProcfileName))
+	if err != nil {
+		return nil, err
+	}
+	procfile := make(map[string]string, len(entries))
+	for _, e := range entries {
+		procfile[e.Name] = e.Command
+	}
+
+	var procs []Process
+	services := make(map[string]bool)
+	for _, svc := range p.Services {
+		services[svc.Name] = true
+	}
+	for _, svc := range p.HostServices() {
+		command := cmp.Or(svc.Command, procfile[svc.Name])
+		if command == "" {
+			continue
+		}
+		procs = append(procs, Process{Name: svc.Name, Command: command, Dir: filepath.Join(p.Dir, svc.Workdir), Port: svc.Port})
+	}
+	if p.HostMode {
+		for _, e := range entries {
+			if !services[e.Name] {
+				procs = append(procs, Process{Name: e.Name, Command: e.Command, Dir: p.Dir})
+			}
+		}
+	}
+	return procs, nil
+}
+
+// Run supervises procs until ctx is done, then stops them. Each process's
+// log starts out empty, as a recreated container's does.
+func Run(ctx context.Context, project string, procs []Process) error {
+	if err := os.MkdirAll(LogDir(project), 0700); err != nil {
+		return err
+	}
+	logs := make([]*os.File, len(procs))
+	for i, proc := range procs {
+		f, err := os.Create(LogPath(project, proc.Name))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		logs[i] = f
+	}
+
+	var wg sync.WaitGroup
+	for i, proc := range procs {
+		f := logs[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out := timestampWriter(f)
+			defer out.Flush()
+			supervise(ctx, proc, out)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// supervise runs proc until ctx is done, restarting it whenever it exits.
+// The delay before a restart doubles after each quick crash, up to
+// maxBackoff, and resets once the process stays up for stableAfter.
+func supervise(ctx context.Context, proc Process, out io.Writer) {
+	backoff := minBackoff
+	for {
+		fmt.Fprintf(out, "[di] starting %s: %s\n", proc.Name, proc.Command)
+		started := time.Now()
+		err := runProcess(ctx, proc, out)
+		if ctx.Err() != nil {
+			fmt.Fprintf(out, "[di] stopped %s\n", proc.Name)
+			return
+		}
+		if time.Since(started) >= stableAfter {
+			backoff = minBackoff
+		}
+		fmt.Fprintf(out, "[di] %s exited (%s); restarting in %s\n", proc.Name, exitStatus(err), backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// runProcess runs proc's command through sh in its own process group, so the
+// whole tree it spawns can be stopped, until it exits or ctx is done.
+func runProcess(ctx context.Context, proc Process, out io.Writer) error {
+	cmd := exec.Command("sh", "-c", proc.Command)
+	cmd.Dir = proc.Dir
+	cmd.Env = os.Environ()
+	if proc.Port != 0 {
+		cmd.Env = append(cmd.Env, "PORT="+strconv.Itoa(proc.Port))
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	// Children left holding the output pipe must not block Wait
+	cmd.WaitDelay = time.Second
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pgid := cmd.Process.Pid
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		// Don't leave behind anything the command started
+		_ = syscall.Kill(-pgid, syscall.SIGTERM)
+		return err
+	case <-ctx.Done():
+	}
+
+	_ = syscall.Kill(-pgid, syscall.SIGTERM)
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(stopTimeout):
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+		return <-done
+	}
+}
+
+// exitStatus describes how a process ended.
+func exitStatus(err error) string {
+	if err == nil {
+		return "status 0"
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ProcessState.String()
+	}
+	return err.Error()
+}
+
+// timestampWriter writes each line to w prefixed with an RFC 3339 timestamp,
+// the format compose uses with --timestamps.
+func timestampWriter(w io.Writer) *ui.LineWriter {
+	return ui.NewLineWriter(func(line string) {
+		fmt.Fprintf(w, "%s %s\n", time.Now().Format(time.RFC3339Nano), line)
+	})
+}
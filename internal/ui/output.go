@@ -3,6 +3,7 @@ package ui
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/fatih/color"
@@ -17,17 +18,17 @@ var (
 
 // Info prints an informational message to stderr.
 func Info(msg string, args ...any) {
-	fmt.Fprintf(os.Stderr, "%s %s\n", cyan.Sprint("[INFO]"), fmt.Sprintf(msg, args...))
+	InfoTo(os.Stderr, msg, args...)
 }
 
 // Ok prints a success message to stderr.
 func Ok(msg string, args ...any) {
-	fmt.Fprintf(os.Stderr, "%s %s\n", green.Sprint("[OK]"), fmt.Sprintf(msg, args...))
+	OkTo(os.Stderr, msg, args...)
 }
 
 // Warn prints a warning message to stderr.
 func Warn(msg string, args ...any) {
-	fmt.Fprintf(os.Stderr, "%s %s\n", yellow.Sprint("[WARN]"), fmt.Sprintf(msg, args...))
+	WarnTo(os.Stderr, msg, args...)
 }
 
 // Fail prints an error message to stderr.
@@ -35,6 +36,22 @@ func Fail(msg string, args ...any) {
 	fmt.Fprintf(os.Stderr, "%s %s\n", red.Sprint("[FAIL]"), fmt.Sprintf(msg, args...))
 }
 
+// InfoTo prints an informational message to w, e.g. a project's prefixed
+// output during a parallel run.
+func InfoTo(w io.Writer, msg string, args ...any) {
+	fmt.Fprintf(w, "%s %s\n", cyan.Sprint("[INFO]"), fmt.Sprintf(msg, args...))
+}
+
+// OkTo prints a success message to w.
+func OkTo(w io.Writer, msg string, args ...any) {
+	fmt.Fprintf(w, "%s %s\n", green.Sprint("[OK]"), fmt.Sprintf(msg, args...))
+}
+
+// WarnTo prints a warning message to w.
+func WarnTo(w io.Writer, msg string, args ...any) {
+	fmt.Fprintf(w, "%s %s\n", yellow.Sprint("[WARN]"), fmt.Sprintf(msg, args...))
+}
+
 // PrintJSON writes a JSON-encoded value to stdout.
 func PrintJSON(v any) error {
 	enc := json.NewEncoder(os.Stdout)
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"bytes"
+	"hash/fnv"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// LineWriter calls a function with each complete line written to it, without
+// the line ending, holding partial lines until they are terminated.
+type LineWriter struct {
+	fn  func(line string)
+	buf bytes.Buffer
+}
+
+// NewLineWriter returns a LineWriter calling fn for each line.
+func NewLineWriter(fn func(line string)) *LineWriter {
+	return &LineWriter{fn: fn}
+}
+
+func (lw *LineWriter) Write(b []byte) (int, error) {
+	lw.buf.Write(b)
+	for {
+		line, err := lw.buf.ReadString('\n')
+		if err != nil {
+			// Incomplete line: keep it for the next write
+			lw.buf.Reset()
+			lw.buf.WriteString(line)
+			return len(b), nil
+		}
+		lw.fn(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// Flush passes on any unterminated trailing output.
+func (lw *LineWriter) Flush() {
+	if lw.buf.Len() > 0 {
+		lw.fn(strings.TrimRight(lw.buf.String(), "\r"))
+		lw.buf.Reset()
+	}
+}
+
+// NewPrefixWriter returns a LineWriter that writes each line to w with a
+// prefix. Writers sharing mu never interleave lines, so several processes
+// can stream to one terminal.
+func NewPrefixWriter(mu *sync.Mutex, w io.Writer, prefix string) *LineWriter {
+	return NewLineWriter(func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = io.WriteString(w, prefix+line+"\n")
+	})
+}
+
+// labelColors are the colors Label picks from.
+var labelColors = []*color.Color{
+	color.New(color.FgCyan),
+	color.New(color.FgGreen),
+	color.New(color.FgYellow),
+	color.New(color.FgBlue),
+	color.New(color.FgMagenta),
+	color.New(color.FgHiCyan),
+	color.New(color.FgHiGreen),
+	color.New(color.FgHiYellow),
+	color.New(color.FgHiBlue),
+	color.New(color.FgHiMagenta),
+}
+
+// Label returns s in a color chosen by hashing key, so a stream keeps the
+// same color across lines and runs.
+func Label(key, s string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return labelColors[h.Sum32()%uint32(len(labelColors))].Sprint(s)
+}